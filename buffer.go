@@ -0,0 +1,80 @@
+package slogf
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/ssgreg/logf"
+)
+
+// maxPooledFields is the maximum capacity of a field buffer that is returned to the pool,
+// so that occasional records with many attributes do not make the pool hold large buffers.
+const maxPooledFields = 256
+
+// ---
+
+// handleBuffer holds the memory used to build the fields of a single record.
+// A nil handleBuffer allocates new memory instead of reusing it.
+type handleBuffer struct {
+	fields []logf.Field
+	enc    groupEncoder
+	nodes  int
+}
+
+// newFields returns an empty slice of fields with at least the given capacity.
+func (b *handleBuffer) newFields(capacity int) []logf.Field {
+	if b == nil {
+		return make([]logf.Field, 0, capacity)
+	}
+
+	return slices.Grow(b.fields[:0], capacity)
+}
+
+// keepFields keeps the given fields, so that their memory is reused for the next record.
+func (b *handleBuffer) keepFields(fields []logf.Field) {
+	if b != nil {
+		b.fields = fields
+	}
+}
+
+// newEncoder returns a group encoder for the given handler fields and groups.
+func (b *handleBuffer) newEncoder(fields []logf.Field, groups []group) *groupEncoder {
+	if b == nil {
+		return &groupEncoder{fields, groups, 0, nil}
+	}
+
+	b.enc = groupEncoder{fields, groups, 0, nil}
+
+	return &b.enc
+}
+
+// ---
+
+// bufferPool is a pool of handle buffers shared by a handler and all handlers derived from it.
+// It is used only if enabled by Handler.WithBufferReuse.
+type bufferPool struct {
+	pool sync.Pool
+}
+
+func newBufferPool() *bufferPool {
+	return &bufferPool{sync.Pool{
+		New: func() any {
+			return &handleBuffer{}
+		},
+	}}
+}
+
+func (p *bufferPool) get() *handleBuffer {
+	return p.pool.Get().(*handleBuffer) //nolint:forcetypeassert // only handle buffers are put to the pool
+}
+
+func (p *bufferPool) put(buf *handleBuffer) {
+	if cap(buf.fields) > maxPooledFields {
+		return
+	}
+
+	clear(buf.fields)
+	buf.fields = buf.fields[:0]
+	buf.enc = groupEncoder{}
+	p.pool.Put(buf)
+}
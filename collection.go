@@ -0,0 +1,327 @@
+package slogf
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ssgreg/logf"
+)
+
+// logfArray converts the given values to a logf array field.
+// Elements are converted the same way as attribute values, groups become objects.
+//
+// The logf JSON encoder does not write a separator before array elements encoded with EncodeTypeAny,
+// so if there is such an element after the first one, the array is passed as a json.Marshaler
+// which encodes the converted elements adding the missing separators, see array.MarshalJSON.
+func logfArray[T any](c converter, key string, values []T, value func(T) slog.Value) logf.Field {
+	if c.depthExceeded() {
+		return logf.String(key, maxDepthMarker)
+	}
+
+	c = c.nested(key)
+	limit := limited(len(values), c.settings.limits.MaxElements)
+
+	elements := make([]logf.Field, 0, limit+1)
+	for _, v := range values[:limit] {
+		if ok, first := c.takeNode(); !ok {
+			if first {
+				elements = append(elements, logf.String("", maxNodesMarker))
+			}
+
+			break
+		}
+
+		elements = append(elements, c.elementField(value(v)))
+	}
+
+	if limit < len(values) {
+		elements = append(elements, logf.String("", truncatedSuffix(len(values)-limit)))
+	}
+
+	a := &array{elements}
+	if a.needsSeparators() {
+		return logf.Field{Key: key, Type: logf.FieldTypeAny, Any: safeMarshaler{a}}
+	}
+
+	return logf.Array(key, a)
+}
+
+// truncatedTypedSlice converts the typed slices supported by logf.Any to a logf array field
+// if they have more elements than allowed by the limits.
+func (c converter) truncatedTypedSlice(key string, value any) (logf.Field, bool) {
+	switch v := value.(type) {
+	case []bool:
+		return truncatedSlice(c, key, v, slog.BoolValue)
+	case []int64:
+		return truncatedSlice(c, key, v, slog.Int64Value)
+	case []int32:
+		return truncatedSlice(c, key, v, int64Value[int32])
+	case []int16:
+		return truncatedSlice(c, key, v, int64Value[int16])
+	case []int8:
+		return truncatedSlice(c, key, v, int64Value[int8])
+	case []uint:
+		return truncatedSlice(c, key, v, uint64Value[uint])
+	case []uint64:
+		return truncatedSlice(c, key, v, slog.Uint64Value)
+	case []uint32:
+		return truncatedSlice(c, key, v, uint64Value[uint32])
+	case []uint16:
+		return truncatedSlice(c, key, v, uint64Value[uint16])
+	case []float64:
+		return truncatedSlice(c, key, v, slog.Float64Value)
+	case []float32:
+		return truncatedSlice(c, key, v, func(f float32) slog.Value { return slog.Float64Value(float64(f)) })
+	case []time.Duration:
+		return truncatedSlice(c, key, v, slog.DurationValue)
+	default:
+		return logf.Field{}, false
+	}
+}
+
+func truncatedSlice[T any](c converter, key string, values []T, value func(T) slog.Value) (logf.Field, bool) {
+	if !c.truncatesElements(len(values)) {
+		return logf.Field{}, false
+	}
+
+	return logfArray(c, key, values, value), true
+}
+
+func int64Value[T int8 | int16 | int32](v T) slog.Value {
+	return slog.Int64Value(int64(v))
+}
+
+func uint64Value[T uint | uint16 | uint32](v T) slog.Value {
+	return slog.Uint64Value(uint64(v))
+}
+
+// logfMapObject converts the given map to a logf object field.
+// Entries are ordered by key, the same way as encoding/json does.
+func logfMapObject[V any](c converter, key string, m map[string]V, value func(V) slog.Value) logf.Field {
+	if c.depthExceeded() {
+		return logf.String(key, maxDepthMarker)
+	}
+
+	c = c.nested(key)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	limit := limited(len(keys), c.settings.limits.MaxElements)
+
+	fields := make([]logf.Field, 0, limit+1)
+	for _, k := range keys[:limit] {
+		fields = c.appendLogfField(fields, slog.Attr{Key: k, Value: value(m[k])})
+	}
+
+	if limit < len(keys) {
+		fields = append(fields, logf.Int(truncatedKey, len(keys)-limit))
+	}
+
+	return logf.Object(key, &object{fields})
+}
+
+func identity[T any](v T) T {
+	return v
+}
+
+// ---
+
+type array struct {
+	elements []logf.Field
+}
+
+func (a *array) EncodeLogfArray(enc logf.TypeEncoder) error {
+	elements := elementEncoder{enc}
+
+	for _, element := range a.elements {
+		element.Accept(&elements)
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the elements with the default configuration of the logf JSON encoder,
+// which only matters for times and durations passed to logf as they are.
+func (a *array) MarshalJSON() ([]byte, error) {
+	buf := logf.NewBuffer()
+	elements := elementEncoder{logf.NewJSONTypeEncoderFactory.Default().TypeEncoder(buf)}
+
+	buf.AppendByte('[')
+
+	for i, element := range a.elements {
+		if i != 0 && element.Type == logf.FieldTypeAny {
+			buf.AppendByte(',')
+		}
+
+		element.Accept(&elements)
+	}
+
+	buf.AppendByte(']')
+
+	return buf.Bytes(), nil
+}
+
+func (a *array) needsSeparators() bool {
+	for i := 1; i < len(a.elements); i++ {
+		if a.elements[i].Type == logf.FieldTypeAny {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ---
+
+// elementEncoder is a logf.FieldEncoder which encodes fields as array elements ignoring their keys.
+type elementEncoder struct {
+	enc logf.TypeEncoder
+}
+
+func (e *elementEncoder) EncodeFieldAny(_ string, v any) {
+	e.enc.EncodeTypeAny(v)
+}
+
+func (e *elementEncoder) EncodeFieldBool(_ string, v bool) {
+	e.enc.EncodeTypeBool(v)
+}
+
+func (e *elementEncoder) EncodeFieldInt64(_ string, v int64) {
+	e.enc.EncodeTypeInt64(v)
+}
+
+func (e *elementEncoder) EncodeFieldInt32(_ string, v int32) {
+	e.enc.EncodeTypeInt32(v)
+}
+
+func (e *elementEncoder) EncodeFieldInt16(_ string, v int16) {
+	e.enc.EncodeTypeInt16(v)
+}
+
+func (e *elementEncoder) EncodeFieldInt8(_ string, v int8) {
+	e.enc.EncodeTypeInt8(v)
+}
+
+func (e *elementEncoder) EncodeFieldUint64(_ string, v uint64) {
+	e.enc.EncodeTypeUint64(v)
+}
+
+func (e *elementEncoder) EncodeFieldUint32(_ string, v uint32) {
+	e.enc.EncodeTypeUint32(v)
+}
+
+func (e *elementEncoder) EncodeFieldUint16(_ string, v uint16) {
+	e.enc.EncodeTypeUint16(v)
+}
+
+func (e *elementEncoder) EncodeFieldUint8(_ string, v uint8) {
+	e.enc.EncodeTypeUint8(v)
+}
+
+func (e *elementEncoder) EncodeFieldFloat64(_ string, v float64) {
+	e.enc.EncodeTypeFloat64(v)
+}
+
+func (e *elementEncoder) EncodeFieldFloat32(_ string, v float32) {
+	e.enc.EncodeTypeFloat32(v)
+}
+
+func (e *elementEncoder) EncodeFieldDuration(_ string, v time.Duration) {
+	e.enc.EncodeTypeDuration(v)
+}
+
+func (e *elementEncoder) EncodeFieldError(_ string, v error) {
+	if v == nil {
+		e.enc.EncodeTypeString("<nil>")
+
+		return
+	}
+
+	e.enc.EncodeTypeString(v.Error())
+}
+
+func (e *elementEncoder) EncodeFieldTime(_ string, v time.Time) {
+	e.enc.EncodeTypeTime(v)
+}
+
+func (e *elementEncoder) EncodeFieldString(_ string, v string) {
+	e.enc.EncodeTypeString(v)
+}
+
+func (e *elementEncoder) EncodeFieldStrings(_ string, v []string) {
+	e.enc.EncodeTypeStrings(v)
+}
+
+func (e *elementEncoder) EncodeFieldBytes(_ string, v []byte) {
+	e.enc.EncodeTypeBytes(v)
+}
+
+func (e *elementEncoder) EncodeFieldBools(_ string, v []bool) {
+	e.enc.EncodeTypeBools(v)
+}
+
+func (e *elementEncoder) EncodeFieldInts64(_ string, v []int64) {
+	e.enc.EncodeTypeInts64(v)
+}
+
+func (e *elementEncoder) EncodeFieldInts32(_ string, v []int32) {
+	e.enc.EncodeTypeInts32(v)
+}
+
+func (e *elementEncoder) EncodeFieldInts16(_ string, v []int16) {
+	e.enc.EncodeTypeInts16(v)
+}
+
+func (e *elementEncoder) EncodeFieldInts8(_ string, v []int8) {
+	e.enc.EncodeTypeInts8(v)
+}
+
+func (e *elementEncoder) EncodeFieldUints64(_ string, v []uint64) {
+	e.enc.EncodeTypeUints64(v)
+}
+
+func (e *elementEncoder) EncodeFieldUints32(_ string, v []uint32) {
+	e.enc.EncodeTypeUints32(v)
+}
+
+func (e *elementEncoder) EncodeFieldUints16(_ string, v []uint16) {
+	e.enc.EncodeTypeUints16(v)
+}
+
+func (e *elementEncoder) EncodeFieldUints8(_ string, v []uint8) {
+	e.enc.EncodeTypeUints8(v)
+}
+
+func (e *elementEncoder) EncodeFieldFloats64(_ string, v []float64) {
+	e.enc.EncodeTypeFloats64(v)
+}
+
+func (e *elementEncoder) EncodeFieldFloats32(_ string, v []float32) {
+	e.enc.EncodeTypeFloats32(v)
+}
+
+func (e *elementEncoder) EncodeFieldDurations(_ string, v []time.Duration) {
+	e.enc.EncodeTypeDurations(v)
+}
+
+func (e *elementEncoder) EncodeFieldArray(_ string, v logf.ArrayEncoder) {
+	e.enc.EncodeTypeArray(v)
+}
+
+func (e *elementEncoder) EncodeFieldObject(_ string, v logf.ObjectEncoder) {
+	e.enc.EncodeTypeObject(v)
+}
+
+// ---
+
+var (
+	_ logf.ArrayEncoder = (*array)(nil)
+	_ json.Marshaler    = (*array)(nil)
+	_ logf.FieldEncoder = (*elementEncoder)(nil)
+)
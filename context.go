@@ -0,0 +1,35 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextWithHandler returns a copy of ctx carrying a slog.Logger which uses the given handler
+// with the logf logger resolved from ctx once, when ContextWithHandler is called.
+// The logger can be obtained using LoggerFromContext.
+//
+// By default, Handler looks up the logf logger in the context of each record twice, in Enabled and Handle,
+// which is noticeable with deep context chains.
+// Logging with the logger obtained from the context takes a single lookup, the one in LoggerFromContext.
+// Note that changes of the logf logger in the derived contexts do not affect the stored logger,
+// so ContextWithHandler should be called again after the logf logger in the context is replaced.
+func ContextWithHandler(ctx context.Context, handler *Handler) context.Context {
+	logger := slog.New(handler.WithLogger(handler.logger(ctx)))
+
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// LoggerFromContext returns the slog.Logger stored in ctx by ContextWithHandler.
+// If there is no such logger, it returns slog.Default().
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// ---
+
+type contextKey struct{}
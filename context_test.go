@@ -0,0 +1,59 @@
+package slogf_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestContextWithHandler(tt *testing.T) {
+	t := New(tt)
+
+	t.Run("Lookups", func(t Test) {
+		var lookups int
+
+		lines := testLog(testLogf(func(logfLogger *logf.Logger) {
+			ctx := &testCountingContext{logf.NewContext(context.Background(), logfLogger), &lookups}
+
+			slog.New(slogf.NewHandler()).InfoContext(ctx, "test 1")
+			t.Expect(lookups).To(Equal(2))
+
+			lookups = 0
+			ctx = &testCountingContext{slogf.ContextWithHandler(ctx, slogf.NewHandler().WithLevelKey("slog")), &lookups}
+			t.Expect(lookups).To(Equal(1))
+
+			lookups = 0
+			slogf.LoggerFromContext(ctx).InfoContext(ctx, "test 2", slog.Int("a", 1))
+			slogf.LoggerFromContext(ctx).DebugContext(ctx, "test 3")
+			t.Expect(lookups).To(Equal(2))
+		}))
+
+		t.Expect(lines).To(Equal([]string{
+			`{"level":"info","msg":"test 1"}`,
+			`{"level":"info","msg":"test 2","slog":"INFO","a":1}`,
+			`{"level":"debug","msg":"test 3","slog":"DEBUG"}`,
+		}))
+	})
+
+	t.Run("NoLogger", func(t Test) {
+		t.Expect(slogf.LoggerFromContext(context.Background())).To(Equal(slog.Default()))
+	})
+}
+
+// ---
+
+type testCountingContext struct {
+	context.Context
+	lookups *int
+}
+
+func (c *testCountingContext) Value(key any) any {
+	*c.lookups++
+
+	return c.Context.Value(key)
+}
@@ -0,0 +1,132 @@
+package slogf
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/ssgreg/logf"
+)
+
+// DedupPolicy specifies how fields with duplicate keys at the same group level are handled.
+// Group levels include the top level, groups opened by WithGroup and group attributes.
+// Keys of the groups opened by WithGroup take part in deduplication at their parent level
+// the same way as keys of other fields do.
+type DedupPolicy int
+
+// Dedup policies.
+const (
+	// DedupNone keeps all fields, so the output may contain duplicate keys, like slog handlers do.
+	DedupNone DedupPolicy = iota
+	// DedupKeepLast keeps only the last field with each key.
+	DedupKeepLast
+	// DedupKeepFirst keeps only the first field with each key.
+	DedupKeepFirst
+	// DedupRename keeps all fields renaming the duplicates by adding "#N" suffix to their keys,
+	// where N is the number of occurrence of the key starting from 2.
+	DedupRename
+)
+
+func (p DedupPolicy) apply(fields []logf.Field) []logf.Field {
+	if len(fields) < 2 {
+		return fields
+	}
+
+	switch p {
+	case DedupKeepLast:
+		return dedupKeepLast(fields)
+	case DedupKeepFirst:
+		return dedupKeepFirst(fields)
+	case DedupRename:
+		return dedupRename(fields)
+	case DedupNone:
+		fallthrough
+	default:
+		return fields
+	}
+}
+
+// nestFields returns the given handler fields nested into the given handler groups
+// with the record fields appended to the innermost group, all of them deduplicated at each level.
+// Groups with no fields are omitted.
+func (p DedupPolicy) nestFields(builtins, fields []logf.Field, groups []group, recordFields []logf.Field) []logf.Field {
+	level := recordFields
+	end := len(fields)
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		level = p.apply(slices.Concat(fields[groups[i].i:end], level))
+		end = groups[i].i
+
+		if len(level) != 0 {
+			level = []logf.Field{logf.Object(groups[i].name, &object{level})}
+		}
+	}
+
+	return p.apply(slices.Concat(builtins, fields[:end], level))
+}
+
+// ---
+
+func dedupKeepLast(fields []logf.Field) []logf.Field {
+	last := make(map[string]int, len(fields))
+	for i := range fields {
+		last[fields[i].Key] = i
+	}
+
+	if len(last) == len(fields) {
+		return fields
+	}
+
+	result := fields[:0]
+
+	for i := range fields {
+		if last[fields[i].Key] == i {
+			result = append(result, fields[i])
+		}
+	}
+
+	return result
+}
+
+func dedupKeepFirst(fields []logf.Field) []logf.Field {
+	seen := make(map[string]struct{}, len(fields))
+	result := fields[:0]
+
+	for i := range fields {
+		if _, ok := seen[fields[i].Key]; !ok {
+			seen[fields[i].Key] = struct{}{}
+			result = append(result, fields[i])
+		}
+	}
+
+	return result
+}
+
+func dedupRename(fields []logf.Field) []logf.Field {
+	seen := make(map[string]int, len(fields))
+
+	for i := range fields {
+		key := fields[i].Key
+
+		n, ok := seen[key]
+		if !ok {
+			seen[key] = 1
+
+			continue
+		}
+
+		for {
+			n++
+			renamed := key + "#" + strconv.Itoa(n)
+
+			if _, taken := seen[renamed]; !taken {
+				seen[key] = n
+				seen[renamed] = 1
+				fields[i].Key = renamed
+
+				break
+			}
+		}
+	}
+
+	return fields
+}
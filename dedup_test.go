@@ -0,0 +1,102 @@
+package slogf_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestDedupPolicy(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag  LineTag
+		name     string
+		policy   slogf.DedupPolicy
+		expected []string
+	}
+
+	tests := []test{
+		{
+			lineTag: ThisLine(),
+			name:    "None",
+			policy:  slogf.DedupNone,
+			expected: []string{
+				`{"level":"info","msg":"test 1","a":1,"g":0,"g":{"a":2,"a":3,"a":4,"h":{"x":1,"x":2}}}`,
+				`{"level":"info","msg":"test 2","t":"x","t":"y"}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "KeepLast",
+			policy:  slogf.DedupKeepLast,
+			expected: []string{
+				`{"level":"info","msg":"test 1","a":1,"g":{"a":4,"h":{"x":2}}}`,
+				`{"level":"info","msg":"test 2","t":"y"}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "KeepFirst",
+			policy:  slogf.DedupKeepFirst,
+			expected: []string{
+				`{"level":"info","msg":"test 1","a":1,"g":0}`,
+				`{"level":"info","msg":"test 2","t":"x"}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Rename",
+			policy:  slogf.DedupRename,
+			expected: []string{
+				`{"level":"info","msg":"test 1","a":1,"g":0,"g#2":{"a":2,"a#2":3,"a#3":4,"h":{"x":1,"x#2":2}}}`,
+				`{"level":"info","msg":"test 2","t":"x","t#2":"y"}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+			t.Expect(
+				testLog(testLogf(func(logfLogger *logf.Logger) {
+					handler := slogf.NewHandler().WithLogger(logfLogger).WithDedupPolicy(test.policy)
+					slog.New(handler).
+						With(slog.Int("a", 1), slog.Int("g", 0)).
+						WithGroup("g").
+						With(slog.Int("a", 2)).
+						Info("test 1", slog.Int("a", 3), slog.Int("a", 4), slog.Group("h", slog.Int("x", 1), slog.Int("x", 2)))
+					slog.New(handler.WithTimeKey("t").WithTimeFormat(slogf.TimeFormat{Layout: "x"})).
+						Info("test 2", slog.String("t", "y"))
+				})),
+			).To(Equal(test.expected))
+		})
+	}
+
+	t.Run("Rename", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithDedupPolicy(slogf.DedupRename)
+				slog.New(handler).Info("test", slog.Int("a#2", 1), slog.Int("a", 2), slog.Int("a", 3))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","a#2":1,"a":2,"a#3":3}`}))
+	})
+
+	t.Run("EmptyGroups", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithDedupPolicy(slogf.DedupKeepLast)
+				logger := slog.New(handler).WithGroup("g1").With(slog.Int("a", 1)).WithGroup("g2")
+				logger.Info("test 1")
+				logger.WithGroup("g3").Info("test 2", slog.Int("b", 2))
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test 1","g1":{"a":1}}`,
+			`{"level":"info","msg":"test 2","g1":{"a":1,"g2":{"g3":{"b":2}}}}`,
+		}))
+	})
+}
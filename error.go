@@ -0,0 +1,172 @@
+package slogf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ssgreg/logf"
+)
+
+// EncodeError returns an ErrorEncoder which converts errors to logf fields using the given function.
+// The field returned by the function is protected from panics the same way as the fields returned by value converters.
+func EncodeError(encode func(key string, err error) logf.Field) ErrorEncoder {
+	return ErrorEncoder{
+		func(key string, err error, _ int, _ *int) logf.Field {
+			return encode(key, err)
+		},
+	}
+}
+
+// StructuredErrorEncoder returns an ErrorEncoder which converts errors to objects with the following fields:
+//   - "msg" is the error message;
+//   - "type" is the dynamic type of the error;
+//   - "causes" is an array of objects of the same structure for the errors returned by
+//     Unwrap() error or Unwrap() []error methods, it is omitted if there are no such errors;
+//   - "stack" is the stack trace returned by StructuredErrorConfig.Stack, it is omitted if empty.
+//
+// The object is built when the attribute is converted, so it is safe to use with asynchronous writers.
+// Each level of causes counts as a level of nesting limited by Limits.MaxDepth,
+// and each cause counts as a value limited by Limits.MaxNodes,
+// the causes that exceed the limits are replaced with a "!MAXDEPTH" or "!MAXNODES" string.
+func StructuredErrorEncoder(config StructuredErrorConfig) ErrorEncoder {
+	return ErrorEncoder{
+		func(key string, err error, depth int, nodes *int) logf.Field {
+			return logf.Object(key, ptr(config.errorObject(err, depth, nodes)))
+		},
+	}
+}
+
+// ---
+
+// ErrorEncoder converts errors to logf fields.
+// Use EncodeError or StructuredErrorEncoder to create one and Handler.WithErrorEncoder to register it.
+// The zero value is the default encoder, which uses logf.NamedError.
+type ErrorEncoder struct {
+	encode func(key string, err error, depth int, nodes *int) logf.Field
+}
+
+// ---
+
+// StructuredErrorConfig configures an ErrorEncoder returned by StructuredErrorEncoder.
+type StructuredErrorConfig struct {
+	// Stack returns the stack trace of the error, if there is any.
+	// If Stack is nil, the stack trace is not included.
+	// See FormatterErrorStack for an implementation that works with errors implementing fmt.Formatter.
+	Stack func(err error) string
+}
+
+// errorObject builds an object for the error and its causes up to the given number of levels of causes,
+// taking a node from the given budget for each cause.
+func (c StructuredErrorConfig) errorObject(err error, depth int, nodes *int) errorObject {
+	object := errorObject{
+		msg: err.Error(),
+		typ: reflect.TypeOf(err).String(),
+	}
+
+	if c.Stack != nil {
+		object.stack = c.Stack(err)
+	}
+
+	var causes []error
+
+	//nolint:errorlint // only direct causes are needed here
+	switch err := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := err.Unwrap(); cause != nil {
+			causes = []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		causes = err.Unwrap()
+	}
+
+	for _, cause := range causes {
+		if cause == nil {
+			continue
+		}
+
+		if depth <= 0 {
+			object.marker = maxDepthMarker
+
+			break
+		}
+
+		if ok, first := takeNode(nodes); !ok {
+			if first {
+				object.marker = maxNodesMarker
+			}
+
+			break
+		}
+
+		object.causes = append(object.causes, c.errorObject(cause, depth-1, nodes))
+	}
+
+	return object
+}
+
+// ---
+
+// FormatterErrorStack returns the detailed error message produced by the "%+v" verb
+// if the error implements fmt.Formatter and the message differs from the one returned by Error method.
+// Errors created by packages like github.com/pkg/errors include the stack trace in such message.
+// Otherwise, it returns an empty string.
+func FormatterErrorStack(err error) string {
+	//nolint:errorlint // only the error itself is expected to be formatted, not the errors it wraps
+	if _, ok := err.(fmt.Formatter); !ok {
+		return ""
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	if verbose == err.Error() {
+		return ""
+	}
+
+	return verbose
+}
+
+// ---
+
+type errorObject struct {
+	msg    string
+	typ    string
+	stack  string
+	causes errorArray
+	marker string
+}
+
+func (o *errorObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	enc.EncodeFieldString("msg", o.msg)
+	enc.EncodeFieldString("type", o.typ)
+
+	switch {
+	case len(o.causes) != 0:
+		enc.EncodeFieldArray("causes", o.causes)
+	case o.marker != "":
+		enc.EncodeFieldString("causes", o.marker)
+	}
+
+	if o.stack != "" {
+		enc.EncodeFieldString("stack", o.stack)
+	}
+
+	return nil
+}
+
+// ---
+
+type errorArray []errorObject
+
+func (a errorArray) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for i := range a {
+		enc.EncodeTypeObject(&a[i])
+	}
+
+	return nil
+}
+
+// ---
+
+var (
+	_ logf.ObjectEncoder = (*errorObject)(nil)
+	_ logf.ArrayEncoder  = errorArray(nil)
+)
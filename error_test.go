@@ -0,0 +1,180 @@
+package slogf_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestStructuredErrorEncoder(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag  LineTag
+		name     string
+		config   slogf.StructuredErrorConfig
+		err      error
+		expected string
+	}
+
+	tests := []test{
+		{
+			lineTag:  ThisLine(),
+			name:     "Simple",
+			err:      errors.New("failure"),
+			expected: `{"level":"info","msg":"test","err":{"msg":"failure","type":"*errors.errorString"}}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Wrapped",
+			err:     fmt.Errorf("outer: %w", io.EOF),
+			expected: `{"level":"info","msg":"test","err":{"msg":"outer: EOF","type":"*fmt.wrapError",` +
+				`"causes":[{"msg":"EOF","type":"*errors.errorString"}]}}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Joined",
+			err:     errors.Join(io.EOF, fmt.Errorf("wrapped: %w", io.ErrUnexpectedEOF)),
+			expected: `{"level":"info","msg":"test","err":{"msg":"EOF\nwrapped: unexpected EOF","type":"*errors.joinError",` +
+				`"causes":[{"msg":"EOF","type":"*errors.errorString"},{"msg":"wrapped: unexpected EOF","type":"*fmt.wrapError",` +
+				`"causes":[{"msg":"unexpected EOF","type":"*errors.errorString"}]}]}}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Stack",
+			config: slogf.StructuredErrorConfig{
+				Stack: func(err error) string {
+					if errors.Is(err, io.EOF) {
+						return "stack of " + err.Error()
+					}
+
+					return ""
+				},
+			},
+			err: fmt.Errorf("outer: %w", io.EOF),
+			expected: `{"level":"info","msg":"test","err":{"msg":"outer: EOF","type":"*fmt.wrapError",` +
+				`"causes":[{"msg":"EOF","type":"*errors.errorString","stack":"stack of EOF"}],"stack":"stack of outer: EOF"}}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "FormatterStack",
+			config:  slogf.StructuredErrorConfig{Stack: slogf.FormatterErrorStack},
+			err:     testFormatterError{},
+			expected: `{"level":"info","msg":"test","err":{"msg":"failure","type":"slogf_test.testFormatterError",` +
+				`"stack":"failure\nstack"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+			t.Expect(
+				testLog(testLogf(func(logfLogger *logf.Logger) {
+					handler := slogf.NewHandler().WithLogger(logfLogger).WithErrorEncoder(slogf.StructuredErrorEncoder(test.config))
+					slog.New(handler).Info("test", slog.Any("err", test.err))
+				})),
+			).To(Equal([]string{test.expected}))
+		})
+	}
+
+	t.Run("CyclicCauses", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithLimits(slogf.Limits{MaxDepth: 3}).
+					WithErrorEncoder(slogf.StructuredErrorEncoder(slogf.StructuredErrorConfig{}))
+				slog.New(handler).Info("test", slog.Any("err", testCyclicError{}), slog.Group("g", slog.Group("h", slog.Any("err", testCyclicError{}))))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","err":{"msg":"cycle","type":"slogf_test.testCyclicError",` +
+			`"causes":[{"msg":"cycle","type":"slogf_test.testCyclicError","causes":[{"msg":"cycle","type":"slogf_test.testCyclicError",` +
+			`"causes":"!MAXDEPTH"}]}]},"g":{"h":{"err":{"msg":"cycle","type":"slogf_test.testCyclicError","causes":"!MAXDEPTH"}}}}`}))
+	})
+
+	t.Run("BranchingCauses", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithLimits(slogf.Limits{MaxNodes: 4}).
+					WithErrorEncoder(slogf.StructuredErrorEncoder(slogf.StructuredErrorConfig{}))
+				slog.New(handler).Info("test", slog.Any("err", testBranchingError{}))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","err":{"msg":"branch","type":"slogf_test.testBranchingError",` +
+			`"causes":[{"msg":"branch","type":"slogf_test.testBranchingError","causes":[{"msg":"branch","type":"slogf_test.testBranchingError",` +
+			`"causes":[{"msg":"branch","type":"slogf_test.testBranchingError","causes":"!MAXNODES"}]}]}]}}`}))
+	})
+
+	t.Run("Custom", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithErrorEncoder(slogf.EncodeError(func(key string, err error) logf.Field {
+					if errors.Is(err, io.EOF) {
+						return logf.Object(key, testPanicObject{})
+					}
+
+					return logf.String(key, "custom: "+err.Error())
+				}))
+				slog.New(handler).Info("test", slog.Any("a", io.ErrUnexpectedEOF), slog.Any("b", io.EOF), slog.Int("x", 1))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","a":"custom: unexpected EOF","b":{"x":1,"!PANIC":"!PANIC: object"},"x":1}`}))
+	})
+
+	t.Run("Default", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithErrorEncoder(slogf.StructuredErrorEncoder(slogf.StructuredErrorConfig{}))
+				slog.New(handler.WithErrorEncoder(slogf.ErrorEncoder{})).Info("test", slog.Any("err", io.EOF))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","err":"EOF"}`}))
+	})
+}
+
+// ---
+
+type testFormatterError struct{}
+
+func (testFormatterError) Error() string {
+	return "failure"
+}
+
+func (e testFormatterError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = io.WriteString(s, e.Error()+"\nstack")
+
+		return
+	}
+
+	_, _ = io.WriteString(s, e.Error())
+}
+
+// ---
+
+type testCyclicError struct{}
+
+func (testCyclicError) Error() string {
+	return "cycle"
+}
+
+func (e testCyclicError) Unwrap() error {
+	return e
+}
+
+// ---
+
+type testBranchingError struct{}
+
+func (testBranchingError) Error() string {
+	return "branch"
+}
+
+func (e testBranchingError) Unwrap() []error {
+	return []error{e, e}
+}
+
+// ---
+
+var _ fmt.Formatter = testFormatterError{}
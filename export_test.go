@@ -0,0 +1,12 @@
+package slogf
+
+// SetLoggerLayoutError replaces the result of the logf.Logger layout check
+// until the returned function is called.
+func SetLoggerLayoutError(err error) func() {
+	saved := loggerLayoutError
+	loggerLayoutError = err
+
+	return func() {
+		loggerLayoutError = saved
+	}
+}
@@ -1,52 +1,430 @@
 package slogf
 
 import (
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"slices"
+	"sync"
+	"time"
+	"unsafe"
 
 	"github.com/ssgreg/logf"
 )
 
-func logfField(attr slog.Attr) (logf.Field, bool) {
+// converter converts slog attributes to logf fields according to the handler options and settings.
+type converter struct {
+	replace  func(groups []string, a slog.Attr) slog.Attr
+	settings *settings
+	groups   []string
+	depth    int
+	nodes    *int
+}
+
+// settings holds conversion settings configured by the Handler builder methods.
+// It is never modified once a handler is created, a new copy is made instead.
+type settings struct {
+	values    valueConverters
+	errors    ErrorEncoder
+	durations DurationFormat
+	times     TimeFormat
+	limits    Limits
+	dedup     DedupPolicy
+	redaction *redactor
+}
+
+func (c converter) appendLogfFields(fields []logf.Field, attrs ...slog.Attr) []logf.Field {
+	for _, attr := range attrs {
+		fields = c.appendLogfField(fields, attr)
+	}
+
+	return fields
+}
+
+// appendLazyLogfFields is like appendLogfFields but defers conversion of attributes containing slog.LogValuer values
+// at any depth, adding placeholder fields for them instead. It returns true if any placeholder fields were added.
+// Placeholder fields are expanded by expandLazyFields.
+func (c converter) appendLazyLogfFields(fields []logf.Field, attrs ...slog.Attr) ([]logf.Field, bool) {
+	lazy := false
+
+	for _, attr := range attrs {
+		if hasLogValuer(attr.Value) {
+			fields = append(fields, logf.Field{Type: logf.FieldTypeUnknown, Any: &lazyAttr{conv: c, attr: attr}})
+			lazy = true
+		} else {
+			fields = c.appendLogfField(fields, attr)
+		}
+	}
+
+	return fields, lazy
+}
+
+func (c converter) appendLogfField(fields []logf.Field, attr slog.Attr) []logf.Field {
+	if ok, first := c.takeNode(); !ok {
+		if first && attr.Key != "" {
+			return append(fields, logf.String(attr.Key, maxNodesMarker))
+		}
+
+		return fields
+	}
+
+	if redacted, ok := c.settings.redaction.redact(c.groups, attr); ok {
+		attr.Value = slog.StringValue(redacted)
+	} else {
+		attr.Value = attr.Value.Resolve()
+	}
+
+	if c.replace != nil && attr.Value.Kind() != slog.KindGroup {
+		attr = c.replace(c.groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+
 	if attr.Equal(slog.Attr{}) {
-		return logf.Field{}, false
+		return fields
+	}
+
+	if attr.Value.Kind() != slog.KindGroup {
+		return append(fields, c.safeLogfField(attr))
+	}
+
+	attrs := attr.Value.Group()
+
+	switch {
+	case len(attrs) == 0:
+		return fields
+	case attr.Key == "":
+		if c.depthExceeded() {
+			// An inline group has no key to put the marker to, so it is omitted.
+			return fields
+		}
+
+		return c.deeper().appendGroupFields(fields, attrs)
+	case c.depthExceeded():
+		return append(fields, logf.String(attr.Key, maxDepthMarker))
+	default:
+		groupFields := c.group(attr.Key).appendGroupFields(make([]logf.Field, 0, len(attrs)), attrs)
+		groupFields = c.settings.dedup.apply(groupFields)
+		if len(groupFields) == 0 {
+			return fields
+		}
+
+		return append(fields, logf.Object(attr.Key, &object{groupFields}))
+	}
+}
+
+// appendGroupFields appends the fields converted from the attributes of a group,
+// truncating them according to the limits.
+func (c converter) appendGroupFields(fields []logf.Field, attrs []slog.Attr) []logf.Field {
+	limit := limited(len(attrs), c.settings.limits.MaxAttrs)
+	if limit == len(attrs) {
+		return c.appendLogfFields(fields, attrs...)
+	}
+
+	fields = c.appendLogfFields(fields, attrs[:limit]...)
+
+	return append(fields, logf.Int(truncatedKey, len(attrs)-limit))
+}
+
+// elementField converts the given value to a field representing an array element.
+func (c converter) elementField(value slog.Value) logf.Field {
+	if secret, ok := secretOf(value); ok {
+		return logf.String("", c.settings.redaction.redactSecret(secret))
+	}
+
+	value = value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return c.safeLogfField(slog.Attr{Value: value})
+	}
+
+	if c.depthExceeded() {
+		return logf.String("", maxDepthMarker)
+	}
+
+	return logf.Object("", &object{c.deeper().appendGroupFields(nil, value.Group())})
+}
+
+// nested returns a converter for the contents of slices and maps with the given key,
+// which are not passed to ReplaceAttr, like slog handlers do not pass them.
+// Elements of slices have empty keys and, like groups in slices, add no segments to redaction paths.
+func (c converter) nested(key string) converter {
+	c.replace = nil
+
+	if key == "" {
+		return c.deeper()
+	}
+
+	return c.group(key)
+}
+
+func (c converter) group(key string) converter {
+	if c.replace != nil || c.settings.redaction.usesPaths() {
+		c.groups = append(slices.Clip(c.groups), key)
+	}
+
+	return c.deeper()
+}
+
+func (c converter) deeper() converter {
+	c.depth++
+
+	return c.withBudget()
+}
+
+func (c converter) truncatesElements(n int) bool {
+	return limited(n, c.settings.limits.MaxElements) != n
+}
+
+func (c converter) depthExceeded() bool {
+	return c.depth >= c.settings.limits.maxDepth()
+}
+
+// budgeted returns a converter which takes nodes from the given budget, see takeNode.
+func (c converter) budgeted(nodes *int) converter {
+	*nodes = c.settings.limits.maxNodes()
+	c.nodes = nodes
+
+	return c
+}
+
+// withBudget returns the converter itself if it has a budget, or a converter with a new budget otherwise.
+// A converter without a budget converts any number of attributes, but gets a new budget for nested values, see deeper.
+func (c converter) withBudget() converter {
+	if c.nodes != nil {
+		return c
 	}
 
+	return c.budgeted(new(int))
+}
+
+// takeNode takes a node from the budget before an attribute or an element is converted, see takeNode function.
+func (c converter) takeNode() (ok, first bool) {
+	return takeNode(c.nodes)
+}
+
+// takeNode takes a node from the given budget, if it is not nil.
+// If the budget is exhausted, it returns false, and true as the second result for the first node that does not fit,
+// which is replaced with a marker, while the following ones are omitted.
+func takeNode(nodes *int) (ok, first bool) {
+	switch {
+	case nodes == nil:
+		return true, false
+	case *nodes > 0:
+		*nodes--
+
+		return true, false
+	default:
+		first = *nodes == 0
+		*nodes = -1
+
+		return false, first
+	}
+}
+
+// mayNest reports whether converting the value may convert nested values.
+func mayNest(value slog.Value) bool {
+	switch value.Kind() {
+	case slog.KindAny, slog.KindGroup, slog.KindLogValuer:
+		return true
+	default:
+		return false
+	}
+}
+
+func hasLogValuer(value slog.Value) bool {
+	switch value.Kind() {
+	case slog.KindLogValuer:
+		return true
+	case slog.KindGroup:
+		return slices.ContainsFunc(value.Group(), func(attr slog.Attr) bool {
+			return hasLogValuer(attr.Value)
+		})
+	default:
+		return false
+	}
+}
+
+// safeLogfField is like logfField but recovers from panics in the code called during conversion,
+// like LogValue, String or Error methods, replacing the value with a placeholder.
+func (c converter) safeLogfField(attr slog.Attr) (field logf.Field) {
+	defer func() {
+		if r := recover(); r != nil {
+			field = logf.String(attr.Key, panicMessage(r, attr.Value.Any()))
+		}
+	}()
+
+	return c.logfField(attr)
+}
+
+func (c converter) logfField(attr slog.Attr) logf.Field {
 	switch attr.Value.Kind() {
 	case slog.KindBool:
-		return logf.Bool(attr.Key, attr.Value.Bool()), true
+		return logf.Bool(attr.Key, attr.Value.Bool())
 	case slog.KindDuration:
-		return logf.Duration(attr.Key, attr.Value.Duration()), true
+		return c.settings.durations.logfField(attr.Key, attr.Value.Duration())
 	case slog.KindFloat64:
-		return logf.Float64(attr.Key, attr.Value.Float64()), true
+		return logf.Float64(attr.Key, attr.Value.Float64())
 	case slog.KindInt64:
-		return logf.Int64(attr.Key, attr.Value.Int64()), true
+		return logf.Int64(attr.Key, attr.Value.Int64())
 	case slog.KindString:
-		return logf.String(attr.Key, attr.Value.String()), true
+		return logf.String(attr.Key, c.settings.limits.truncateString(attr.Value.String()))
 	case slog.KindTime:
-		return logf.Time(attr.Key, attr.Value.Time()), true
+		return c.settings.times.logfField(attr.Key, attr.Value.Time())
 	case slog.KindUint64:
-		return logf.Uint64(attr.Key, attr.Value.Uint64()), true
-	case slog.KindGroup:
-		return logf.Object(attr.Key, &object{logfFields(attr.Value.Group()...)}), true
-	case slog.KindLogValuer:
-		return logfField(slog.Attr{Key: attr.Key, Value: attr.Value.Resolve()})
-	case slog.KindAny:
+		return logf.Uint64(attr.Key, attr.Value.Uint64())
+	case slog.KindAny, slog.KindGroup, slog.KindLogValuer:
 		fallthrough
 	default:
-		return logf.Any(attr.Key, attr.Value.Any()), true
+		return c.logfAnyField(attr.Key, attr.Value.Any())
 	}
 }
 
-func logfFields(attrs ...slog.Attr) []logf.Field {
-	fields := make([]logf.Field, 0, len(attrs))
+// logfAnyField converts a value of slog.KindAny to a logf field.
+// Registered value converters are consulted first.
+// Common types are converted to the dedicated typed fields to avoid reflection-based encoding.
+// Common slices and maps are converted to logf arrays and objects with their elements converted recursively.
+// Values implementing logf.ObjectEncoder or logf.ArrayEncoder are encoded by logf.
+// Values implementing json.Marshaler take precedence over errors and fmt.Stringer,
+// the same way as they do in slog.JSONHandler.
+func (c converter) logfAnyField(key string, value any) logf.Field {
+	var field logf.Field
 
-	for _, attr := range attrs {
-		if field, ok := logfField(attr); ok {
-			fields = append(fields, field)
+	if convert := c.settings.values.find(value); convert != nil {
+		field = convert(key, value)
+		snapshotField(&field)
+		protectField(&field)
+
+		return field
+	}
+
+	switch v := value.(type) {
+	case slog.Level:
+		return logf.String(key, v.String())
+	case []byte:
+		if s, truncated := c.settings.limits.truncateBytes(v); truncated {
+			return logf.String(key, s)
+		}
+
+		field = logf.Bytes(key, v)
+	case []string:
+		if c.truncatesElements(len(v)) || c.settings.limits.MaxStringLength > 0 {
+			return logfArray(c, key, v, slog.StringValue)
+		}
+
+		return logf.Strings(key, slices.Clone(v))
+	case []int:
+		if c.truncatesElements(len(v)) {
+			return logfArray(c, key, v, slog.IntValue)
+		}
+
+		field = logf.Ints(key, v)
+	case []any:
+		return logfArray(c, key, v, slog.AnyValue)
+	case []slog.Value:
+		return logfArray(c, key, v, identity)
+	case map[string]any:
+		return logfMapObject(c, key, v, slog.AnyValue)
+	case map[string]slog.Value:
+		return logfMapObject(c, key, v, identity)
+	case map[string]string:
+		return logfMapObject(c, key, v, slog.StringValue)
+	case map[string]int:
+		return logfMapObject(c, key, v, slog.IntValue)
+	case map[string]int64:
+		return logfMapObject(c, key, v, slog.Int64Value)
+	case map[string]float64:
+		return logfMapObject(c, key, v, slog.Float64Value)
+	case map[string]bool:
+		return logfMapObject(c, key, v, slog.BoolValue)
+	case *time.Time:
+		if v == nil {
+			return logf.Field{Key: key, Type: logf.FieldTypeAny}
+		}
+
+		return c.settings.times.logfField(key, *v)
+	case logf.ObjectEncoder:
+		field = logf.Object(key, v)
+	case logf.ArrayEncoder:
+		field = logf.Array(key, v)
+	case json.Marshaler:
+		field = logf.Field{Key: key, Type: logf.FieldTypeAny, Any: v}
+	case error:
+		if c.settings.errors.encode != nil {
+			return c.errorField(key, v)
 		}
+
+		return logf.NamedError(key, safeError(v))
+	case fmt.Stringer:
+		return logf.String(key, c.settings.limits.truncateString(v.String()))
+	default:
+		if array, ok := c.truncatedTypedSlice(key, v); ok {
+			return array
+		}
+
+		field = logf.Any(key, v)
 	}
 
-	return fields
+	snapshotField(&field)
+	protectField(&field)
+
+	return field
+}
+
+// errorField converts the error using the registered error encoder.
+// The encoder gets the number of levels of nesting left below the error object.
+func (c converter) errorField(key string, err error) logf.Field {
+	if c.depthExceeded() {
+		return logf.String(key, maxDepthMarker)
+	}
+
+	c = c.withBudget()
+	field := c.settings.errors.encode(key, err, c.settings.limits.maxDepth()-c.depth-1, c.nodes)
+	snapshotField(&field)
+	protectField(&field)
+
+	return field
+}
+
+// snapshotField makes a copy of the field data that may be modified by the caller after the record is handled.
+// It does the same thing as logf.Logger does for the fields passed to it.
+func snapshotField(field *logf.Field) {
+	switch field.Type {
+	case logf.FieldTypeRawBytes:
+		snapshotRawBytes[byte](field, logf.FieldTypeBytes)
+	case logf.FieldTypeRawBytesToBools:
+		snapshotRawBytes[bool](field, logf.FieldTypeBytesToBools)
+	case logf.FieldTypeRawBytesToInts64:
+		snapshotRawBytes[int64](field, logf.FieldTypeBytesToInts64)
+	case logf.FieldTypeRawBytesToInts32:
+		snapshotRawBytes[int32](field, logf.FieldTypeBytesToInts32)
+	case logf.FieldTypeRawBytesToInts16:
+		snapshotRawBytes[int16](field, logf.FieldTypeBytesToInts16)
+	case logf.FieldTypeRawBytesToInts8:
+		snapshotRawBytes[int8](field, logf.FieldTypeBytesToInts8)
+	case logf.FieldTypeRawBytesToUints64:
+		snapshotRawBytes[uint64](field, logf.FieldTypeBytesToUints64)
+	case logf.FieldTypeRawBytesToUints32:
+		snapshotRawBytes[uint32](field, logf.FieldTypeBytesToUints32)
+	case logf.FieldTypeRawBytesToUints16:
+		snapshotRawBytes[uint16](field, logf.FieldTypeBytesToUints16)
+	case logf.FieldTypeRawBytesToUints8:
+		snapshotRawBytes[uint8](field, logf.FieldTypeBytesToUints8)
+	case logf.FieldTypeRawBytesToFloats64:
+		snapshotRawBytes[float64](field, logf.FieldTypeBytesToFloats64)
+	case logf.FieldTypeRawBytesToFloats32:
+		snapshotRawBytes[float32](field, logf.FieldTypeBytesToFloats32)
+	case logf.FieldTypeRawBytesToDurations:
+		snapshotRawBytes[time.Duration](field, logf.FieldTypeBytesToDurations)
+	case logf.FieldTypeAny:
+		if snapshotter, ok := field.Any.(logf.Snapshotter); ok {
+			field.Any = snapshotter.TakeSnapshot()
+		}
+	}
+}
+
+func snapshotRawBytes[T any](field *logf.Field, fieldType logf.FieldType) {
+	values := slices.Clone(*(*[]T)(unsafe.Pointer(&field.Bytes)))
+	field.Bytes = *(*[]byte)(unsafe.Pointer(&values))
+	field.Type = fieldType
 }
 
 // ---
@@ -65,4 +443,47 @@ func (o *object) EncodeLogfObject(enc logf.FieldEncoder) error {
 
 // ---
 
+// lazyAttr is an attribute which conversion is deferred until it is needed for the first time.
+type lazyAttr struct {
+	once   sync.Once
+	conv   converter
+	attr   slog.Attr
+	fields []logf.Field
+}
+
+func (a *lazyAttr) appendLogfFields(fields []logf.Field) []logf.Field {
+	a.once.Do(func() {
+		a.fields = a.conv.budgeted(new(int)).appendLogfField(nil, a.attr)
+	})
+
+	return append(fields, a.fields...)
+}
+
+// expandLazyFields returns fields with placeholder fields replaced with the fields of the converted attributes
+// and groups with indexes adjusted accordingly.
+func expandLazyFields(fields []logf.Field, groups []group) ([]logf.Field, []group) {
+	expandedFields := make([]logf.Field, 0, len(fields))
+	expandedGroups := make([]group, 0, len(groups))
+
+	for i, field := range fields {
+		for len(expandedGroups) < len(groups) && groups[len(expandedGroups)].i == i {
+			expandedGroups = append(expandedGroups, group{len(expandedFields), groups[len(expandedGroups)].name})
+		}
+
+		if lazy, ok := field.Any.(*lazyAttr); ok && field.Type == logf.FieldTypeUnknown {
+			expandedFields = lazy.appendLogfFields(expandedFields)
+		} else {
+			expandedFields = append(expandedFields, field)
+		}
+	}
+
+	for len(expandedGroups) < len(groups) {
+		expandedGroups = append(expandedGroups, group{len(expandedFields), groups[len(expandedGroups)].name})
+	}
+
+	return expandedFields, expandedGroups
+}
+
+// ---
+
 var _ logf.ObjectEncoder = (*object)(nil)
@@ -0,0 +1,63 @@
+package slogf
+
+import (
+	"time"
+
+	"github.com/ssgreg/logf"
+)
+
+// DurationFormat specifies how values of slog.KindDuration are represented.
+type DurationFormat int
+
+// Duration formats.
+const (
+	// DurationDefault passes durations to logf as they are, so their representation is defined by the logf encoder.
+	DurationDefault DurationFormat = iota
+	// DurationString represents durations as strings returned by time.Duration.String, for example "1.5s".
+	DurationString
+	// DurationSeconds represents durations as floating-point numbers of seconds, for example 1.5.
+	DurationSeconds
+	// DurationNanoseconds represents durations as integer numbers of nanoseconds, for example 1500000000.
+	DurationNanoseconds
+)
+
+func (f DurationFormat) logfField(key string, value time.Duration) logf.Field {
+	switch f {
+	case DurationString:
+		return logf.String(key, value.String())
+	case DurationSeconds:
+		return logf.Float64(key, value.Seconds())
+	case DurationNanoseconds:
+		return logf.Int64(key, int64(value))
+	case DurationDefault:
+		fallthrough
+	default:
+		return logf.Duration(key, value)
+	}
+}
+
+// ---
+
+// TimeFormat specifies how values of slog.KindTime are represented.
+// A zero TimeFormat passes times to logf as they are, so their representation is defined by the logf encoder.
+type TimeFormat struct {
+	// Layout is a layout for time.Time.Format.
+	// If Layout is empty, times are passed to logf as time values.
+	Layout string
+
+	// Location is a time zone to convert times to.
+	// If Location is nil, times are not converted.
+	Location *time.Location
+}
+
+func (f TimeFormat) logfField(key string, value time.Time) logf.Field {
+	if f.Location != nil {
+		value = value.In(f.Location)
+	}
+
+	if f.Layout != "" {
+		return logf.String(key, value.Format(f.Layout))
+	}
+
+	return logf.Time(key, value)
+}
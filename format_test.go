@@ -0,0 +1,101 @@
+package slogf_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestFormat(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag  LineTag
+		name     string
+		handler  func(*slogf.Handler) *slogf.Handler
+		expected string
+	}
+
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	tests := []test{
+		{
+			lineTag: ThisLine(),
+			name:    "Default",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","d":1500000000,"t":"2020-01-02T03:04:05.000000006Z"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "DurationString",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithDurationFormat(slogf.DurationString)
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","d":"1.5s","t":"2020-01-02T03:04:05.000000006Z"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "DurationSeconds",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithDurationFormat(slogf.DurationSeconds)
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","d":1.5,"t":"2020-01-02T03:04:05.000000006Z"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "DurationNanoseconds",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithDurationFormat(slogf.DurationNanoseconds)
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","d":1500000000,"t":"2020-01-02T03:04:05.000000006Z"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "TimeLayout",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithTimeFormat(slogf.TimeFormat{Layout: time.DateTime})
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02 03:04:05","d":1500000000,"t":"2020-01-02 03:04:05"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "TimeLocation",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithTimeFormat(slogf.TimeFormat{Location: zone})
+			},
+			expected: `{"level":"info","msg":"test","time":"2020-01-02T06:04:05.000000006+03:00","d":1500000000,` +
+				`"t":"2020-01-02T06:04:05.000000006+03:00"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "TimeLayoutAndLocation",
+			handler: func(h *slogf.Handler) *slogf.Handler {
+				return h.WithTimeFormat(slogf.TimeFormat{Layout: time.RFC822Z, Location: zone})
+			},
+			expected: `{"level":"info","msg":"test","time":"02 Jan 20 06:04 +0300","d":1500000000,"t":"02 Jan 20 06:04 +0300"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+			t.Expect(
+				testLog(testLogf(func(logfLogger *logf.Logger) {
+					handler := test.handler(slogf.NewHandler().WithLogger(logfLogger).WithTimeKey(slog.TimeKey))
+					record := slog.NewRecord(tm, slog.LevelInfo, "test", 0)
+					record.AddAttrs(slog.Duration("d", 1500*time.Millisecond), slog.Any("t", &tm))
+					t.Expect(handler.Handle(context.Background(), record)).ToSucceed()
+				})),
+			).To(Equal([]string{test.expected}))
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"log/slog"
 	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ssgreg/logf"
 	"github.com/ssgreg/logf/logfc"
@@ -12,77 +14,307 @@ import (
 
 // NewHandler returns a new slog.Handler which uses logf.Logger to log records.
 func NewHandler() *Handler {
-	return &Handler{nil, nil, logfc.Get}
+	return NewHandlerWithOptions(nil)
+}
+
+// NewHandlerWithOptions returns a new slog.Handler which uses logf.Logger to log records.
+// If options is nil, the default options are used.
+func NewHandlerWithOptions(options *HandlerOptions) *Handler {
+	if options == nil {
+		options = &HandlerOptions{}
+	} else {
+		options = ptr(*options)
+	}
+
+	return &Handler{
+		nil, nil, nil, nil, logfc.Get, false, "", "", DefaultLevelMapping(), options, &settings{}, nil,
+		derivedLoggers{},
+	}
 }
 
 // ---
 
 // Handler is a slog.Handler implementation which uses logf.Logger to log records.
+//
+// Attributes passed to WithAttrs that contain slog.LogValuer values at any depth are resolved and converted lazily,
+// when a record passing the level check is handled for the first time, so they cost nothing
+// while the level is disabled. Each such attribute is resolved and converted at most once,
+// and HandlerOptions.ReplaceAttr is called for its contents at that time rather than in WithAttrs.
+//
+// If the logger is set by WithLogger, attributes added by WithAttrs before any group is open
+// are passed to it using logf.Logger.With, so logf encoders encode them once and reuse the result for all records.
+// In this case the fields added by WithTimeKey and WithLevelKey follow those attributes.
+// Loggers provided by a function, like the default one taken from the context, are derived this way
+// only if the handler adds no fields by WithTimeKey and WithLevelKey, so that the order of fields does not change,
+// and only for a base logger seen before, so that loggers used for a single record do not pay for the derivation.
+// A handler keeps the derived loggers for a few most recently used base loggers.
 type Handler struct {
-	fields []logf.Field
-	groups []group
-	logger func(context.Context) *logf.Logger
+	fields     []logf.Field
+	groups     []group
+	groupNames []string
+	expanded   *expandedFields
+	logger     func(context.Context) *logf.Logger
+	fixed      bool
+	timeKey    string
+	levelKey   string
+	levels     LevelMapping
+	options    *HandlerOptions
+	settings   *settings
+	buffers    *bufferPool
+	derived    derivedLoggers
 }
 
 // WithLogger returns a new Handler with the given logger.
 func (h *Handler) WithLogger(logger *logf.Logger) *Handler {
-	return h.WithLoggerFunc(func(context.Context) *logf.Logger {
+	h = h.WithLoggerFunc(func(context.Context) *logf.Logger {
 		return logger
 	})
+	h.fixed = true
+
+	return h
 }
 
 // WithLoggerFunc returns a new Handler with the given logger provider function.
 func (h *Handler) WithLoggerFunc(logger func(context.Context) *logf.Logger) *Handler {
 	h = h.fork()
 	h.logger = logger
+	h.fixed = false
+
+	return h
+}
+
+// WithTimeKey returns a new Handler that adds the record time as a field with the given key.
+// The field is omitted if the record time is zero, as slog handlers do.
+// It is intended to be used with logf encoders that have their own time field disabled
+// because logf encoders do not omit zero entry time.
+// Empty key disables the field, which is the default.
+func (h *Handler) WithTimeKey(key string) *Handler {
+	h = h.fork()
+	h.timeKey = key
+
+	return h
+}
+
+// WithLevelKey returns a new Handler that adds the original slog level of the record as a field with the given key.
+// Because logf has only four levels, records with levels in between, like slog.LevelInfo+2,
+// are logged with the nearest lower logf level, and the field keeps the exact level visible.
+// The field value is formatted like slog handlers do, for example "INFO+2".
+// Custom level names can be provided using HandlerOptions.ReplaceAttr,
+// which receives the field with no groups and a slog.Level value.
+// Empty key disables the field, which is the default.
+func (h *Handler) WithLevelKey(key string) *Handler {
+	h = h.fork()
+	h.levelKey = key
+
+	return h
+}
+
+// WithLevelMapping returns a new Handler which uses the given mapping to convert slog levels to logf levels.
+func (h *Handler) WithLevelMapping(mapping LevelMapping) *Handler {
+	h = h.fork()
+	h.levels = mapping
+
+	return h
+}
+
+// WithValueConverters returns a new Handler which uses the given converters for values of slog.KindAny.
+// Converters are consulted before the default conversion, a later converter for the same type replaces an earlier one.
+// They apply to the attributes of records and to the attributes added by WithAttrs after this call.
+func (h *Handler) WithValueConverters(converters ...ValueConverter) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.values = h.settings.values.with(converters...)
+
+	return h
+}
+
+// WithDurationFormat returns a new Handler which represents duration values in the given format.
+// Like value converters, the format applies to the attributes added by WithAttrs after this call.
+func (h *Handler) WithDurationFormat(format DurationFormat) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.durations = format
+
+	return h
+}
+
+// WithTimeFormat returns a new Handler which represents time values in the given format.
+// It also applies to the record time added by WithTimeKey, but not to the entry time written by logf encoder.
+// Like value converters, the format applies to the attributes added by WithAttrs after this call.
+func (h *Handler) WithTimeFormat(format TimeFormat) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.times = format
+
+	return h
+}
+
+// WithLimits returns a new Handler which limits the size of attribute values according to the given limits.
+// Like value converters, the limits apply to the attributes added by WithAttrs after this call.
+func (h *Handler) WithLimits(limits Limits) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.limits = limits
+
+	return h
+}
+
+// WithDedupPolicy returns a new Handler which handles fields with duplicate keys according to the given policy.
+// The policy applies at each group level across the attributes added by WithAttrs, record attributes,
+// the fields added by WithTimeKey and WithLevelKey, and the attributes of groups.
+// Fields added by logf itself, like the message, level and logger fields, are not taken into account.
+// Group attributes passed to WithAttrs are deduplicated only if the policy was set before.
+// Any policy other than DedupNone makes handling of each record more expensive.
+func (h *Handler) WithDedupPolicy(policy DedupPolicy) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.dedup = policy
+
+	return h
+}
+
+// WithBufferReuse returns a new Handler which reuses the memory used to build the fields of records
+// if enabled is true, so that handling a record does not allocate in the steady state.
+// The memory is reused as soon as the entry writer returns from WriteEntry,
+// so it must only be enabled if neither the entry writer nor its appenders retain the entry fields afterwards,
+// like logf.NewUnbufferedEntryWriter with logf.NewWriteAppender.
+// It must not be enabled with asynchronous entry writers, like the one returned by logf.NewChannelWriter.
+// By default, the memory is allocated for each record.
+func (h *Handler) WithBufferReuse(enabled bool) *Handler {
+	h = h.fork()
+	h.buffers = nil
+
+	if enabled {
+		h.buffers = newBufferPool()
+	}
+
+	return h
+}
+
+// WithRedaction returns a new Handler which redacts sensitive attribute values according to the given rules.
+// It panics if any of the patterns is malformed.
+// Like value converters, the redaction applies to the attributes added by WithAttrs after this call,
+// so it should be configured before any attributes are added.
+func (h *Handler) WithRedaction(redaction Redaction) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.redaction = redaction.redactor()
+
+	return h
+}
+
+// WithErrorEncoder returns a new Handler which uses the given encoder to convert error values.
+// See StructuredErrorEncoder for an encoder which includes error types, causes and stack traces.
+// The zero ErrorEncoder restores the default conversion, which uses logf.NamedError.
+// Values implementing json.Marshaler and values having a registered converter are not passed to the encoder.
+// Like value converters, the encoder applies to the attributes added by WithAttrs after this call.
+func (h *Handler) WithErrorEncoder(encoder ErrorEncoder) *Handler {
+	h = h.fork()
+	h.settings = ptr(*h.settings)
+	h.settings.errors = encoder
 
 	return h
 }
 
 // Enabled returns true if the given level is enabled.
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	var enabled bool
-
-	h.logger(ctx).AtLevel(LogfLevel(level), func(logf.LogFunc) {
-		enabled = true
-	})
+	if !h.levelEnabled(level) {
+		return false
+	}
 
-	return enabled
+	return loggerEnabled(h.logger(ctx), h.levels.LogfLevel(level))
 }
 
 // Handle logs the given record.
 func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.levelEnabled(record.Level) {
+		return nil
+	}
+
+	base := h.logger(ctx)
+	level := h.levels.LogfLevel(record.Level)
+
+	if !loggerEnabled(base, level) {
+		return nil
+	}
+
+	conv := h.converter()
+	hFields, hGroups := h.fields, h.groups
+
+	if h.expanded != nil {
+		hFields, hGroups = h.expanded.get(h)
+	}
+
+	prefix := hFields
+	if len(hGroups) != 0 {
+		prefix = hFields[:hGroups[0].i]
+	}
+
+	if len(prefix) != 0 && h.derivesLogger() {
+		if derived := h.deriveLogger(base, prefix); derived != nil {
+			base = derived
+			prefix = nil
+		}
+	}
+
 	collectAttrs := func(fields []logf.Field) []logf.Field {
 		record.Attrs(func(attr slog.Attr) bool {
-			if field, ok := logfField(attr); ok {
-				fields = append(fields, field)
+			// Without buffer reuse, the node budget of the record is allocated only if it may be needed.
+			if conv.nodes == nil && mayNest(attr.Value) {
+				conv = conv.budgeted(new(int))
 			}
 
+			fields = conv.appendLogfField(fields, attr)
+
 			return true
 		})
 
 		return fields
 	}
 
+	// Without buffer reuse, buf is nil and its methods allocate new memory for each record.
+	var buf *handleBuffer
+	if h.buffers != nil {
+		buf = h.buffers.get()
+		defer h.buffers.put(buf)
+		conv = conv.budgeted(&buf.nodes)
+	}
+
 	var fields []logf.Field
-	if len(h.fields)+record.NumAttrs() != 0 {
-		if len(h.groups) == 0 {
-			fields = make([]logf.Field, 0, record.NumAttrs()+len(h.fields))
-			fields = append(fields, h.fields...)
+
+	if h.settings.dedup != DedupNone {
+		builtins := h.appendBuiltinFields(buf.newFields(h.numBuiltinFields(&record)), &record)
+		buf.keepFields(builtins)
+		recordFields := collectAttrs(make([]logf.Field, 0, record.NumAttrs()))
+		fields = h.settings.dedup.nestFields(builtins, hFields, hGroups, recordFields)
+	} else {
+		n := h.numBuiltinFields(&record) + len(prefix) + record.NumAttrs()
+		if len(hGroups) != 0 {
+			n++
+		}
+
+		fields = h.appendBuiltinFields(buf.newFields(n), &record)
+		fields = append(fields, prefix...)
+
+		if len(hGroups) == 0 {
 			fields = collectAttrs(fields)
+			buf.keepFields(fields)
 		} else {
-			enc := groupEncoder{h, 0, nil}
-			fields = make([]logf.Field, 0, record.NumAttrs()+h.groups[0].i+1)
-			fields = append(fields, h.fields[:h.groups[0].i]...)
-			fields = append(fields, logf.Object(h.groups[0].name, &enc))
+			enc := buf.newEncoder(hFields, hGroups)
+			fields = append(fields, logf.Object(hGroups[0].name, enc))
 			i := len(fields)
 			fields = collectAttrs(fields)
 			enc.suffix = fields[i:]
+			buf.keepFields(fields)
 			fields = fields[:i]
+
+			if len(enc.suffix) == 0 && hGroups[0].i == len(hFields) {
+				fields = fields[:i-1]
+			}
 		}
 	}
 
-	logfLog(record.Level, h.logger(ctx), record.Message, fields...)
+	writeEntry(base, level, &record, fields)
 
 	return nil
 }
@@ -96,10 +328,11 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h = h.fork()
 	h.fields = slices.Grow(h.fields, len(attrs))
 
-	for _, attr := range attrs {
-		if field, ok := logfField(attr); ok {
-			h.fields = append(h.fields, field)
-		}
+	var lazy bool
+	h.fields, lazy = h.converter().budgeted(new(int)).appendLazyLogfFields(h.fields, attrs...)
+
+	if lazy {
+		h.expanded = &expandedFields{}
 	}
 
 	return h
@@ -113,29 +346,99 @@ func (h *Handler) WithGroup(key string) slog.Handler {
 
 	h = h.fork()
 	h.groups = append(h.groups, group{len(h.fields), key})
+	h.groupNames = append(h.groupNames, key)
 
 	return h
 }
 
 func (h *Handler) fork() *Handler {
+	var expanded *expandedFields
+	if h.expanded != nil {
+		expanded = &expandedFields{}
+	}
+
 	h = &Handler{
 		slices.Clip(h.fields),
 		slices.Clip(h.groups),
+		slices.Clip(h.groupNames),
+		expanded,
 		h.logger,
+		h.fixed,
+		h.timeKey,
+		h.levelKey,
+		h.levels,
+		h.options,
+		h.settings,
+		h.buffers,
+		derivedLoggers{},
 	}
 
 	return h
 }
 
-func (h *Handler) groupAttrRange(i int) (int, int) {
-	begin := h.groups[i].i
-	end := len(h.fields)
+// derivesLogger reports whether the handler fields preceding the first group may be passed to a derived logger.
+// A logger provided by a function is derived only if it does not change the order of fields.
+func (h *Handler) derivesLogger() bool {
+	if h.settings.dedup != DedupNone {
+		return false
+	}
+
+	return h.fixed || (h.timeKey == "" && h.levelKey == "")
+}
+
+// deriveLogger returns a logger derived from the given base logger with the given fields,
+// or nil if the base logger provided by a function is seen for the first time.
+// The derived logger is cached, so that logf encoders can reuse the encoded fields.
+func (h *Handler) deriveLogger(base *logf.Logger, fields []logf.Field) *logf.Logger {
+	if derived := h.derived.find(base); derived != nil {
+		return derived
+	}
 
-	if i+1 < len(h.groups) {
-		end = h.groups[i+1].i
+	if !h.fixed && !h.derived.seen(base) {
+		return nil
 	}
 
-	return begin, end
+	// logf.Logger.With may modify the given fields, so they are copied.
+	derived := base.With(slices.Clone(fields)...)
+	h.derived.add(base, derived)
+
+	return derived
+}
+
+// numBuiltinFields returns the maximum number of fields added by appendBuiltinFields for the given record.
+func (h *Handler) numBuiltinFields(record *slog.Record) int {
+	n := 0
+	if h.timeKey != "" && !record.Time.IsZero() {
+		n++
+	}
+
+	if h.levelKey != "" {
+		n++
+	}
+
+	return n
+}
+
+func (h *Handler) appendBuiltinFields(fields []logf.Field, record *slog.Record) []logf.Field {
+	conv := converter{h.options.ReplaceAttr, h.settings, nil, 0, nil}
+
+	if h.timeKey != "" && !record.Time.IsZero() {
+		fields = conv.appendLogfField(fields, slog.Time(h.timeKey, record.Time))
+	}
+
+	if h.levelKey != "" {
+		fields = conv.appendLogfField(fields, slog.Any(h.levelKey, record.Level))
+	}
+
+	return fields
+}
+
+func (h *Handler) levelEnabled(level slog.Level) bool {
+	return h.options.Level == nil || level >= h.options.Level.Level()
+}
+
+func (h *Handler) converter() converter {
+	return converter{h.options.ReplaceAttr, h.settings, h.groupNames, 0, nil}
 }
 
 // ---
@@ -147,22 +450,94 @@ type group struct {
 
 // ---
 
+// expandedFields holds the handler fields and groups with lazy attributes converted.
+type expandedFields struct {
+	once   sync.Once
+	fields []logf.Field
+	groups []group
+}
+
+func (e *expandedFields) get(h *Handler) ([]logf.Field, []group) {
+	e.once.Do(func() {
+		e.fields, e.groups = expandLazyFields(h.fields, h.groups)
+	})
+
+	return e.fields, e.groups
+}
+
+// ---
+
+// numDerivedLoggers is the number of base loggers for which a handler keeps the derived loggers.
+const numDerivedLoggers = 4
+
+type derivedLogger struct {
+	base   *logf.Logger
+	logger *logf.Logger
+}
+
+// derivedLoggers caches the loggers derived from a few most recently used base loggers.
+// It also remembers a few base loggers seen without a derived logger, so that a logger is derived
+// only when its base logger is used again.
+type derivedLoggers struct {
+	entries    [numDerivedLoggers]atomic.Pointer[derivedLogger]
+	candidates [numDerivedLoggers]atomic.Pointer[logf.Logger]
+	next       atomic.Uint32
+	nextSeen   atomic.Uint32
+}
+
+// find returns the logger derived from the given base logger, or nil if there is none.
+func (d *derivedLoggers) find(base *logf.Logger) *logf.Logger {
+	for i := range d.entries {
+		if entry := d.entries[i].Load(); entry != nil && entry.base == base {
+			return entry.logger
+		}
+	}
+
+	return nil
+}
+
+// add stores the logger derived from the given base logger, replacing the oldest one.
+func (d *derivedLoggers) add(base, logger *logf.Logger) {
+	i := (d.next.Add(1) - 1) % numDerivedLoggers
+	d.entries[i].Store(&derivedLogger{base, logger})
+}
+
+// seen reports whether the given base logger has been seen before, and remembers it otherwise.
+func (d *derivedLoggers) seen(base *logf.Logger) bool {
+	for i := range d.candidates {
+		if d.candidates[i].Load() == base {
+			return true
+		}
+	}
+
+	i := (d.nextSeen.Add(1) - 1) % numDerivedLoggers
+	d.candidates[i].Store(base)
+
+	return false
+}
+
+// ---
+
+// groupEncoder encodes the handler fields of the group with index i, the groups opened within it,
+// and the record fields in the innermost group.
 type groupEncoder struct {
-	h      *Handler
+	fields []logf.Field
+	groups []group
 	i      int
 	suffix []logf.Field
 }
 
 func (g *groupEncoder) EncodeLogfObject(enc logf.FieldEncoder) error {
-	begin, end := g.h.groupAttrRange(g.i)
+	begin, end := g.groupAttrRange(g.i)
 	for i := begin; i != end; i++ {
-		g.h.fields[i].Accept(enc)
+		g.fields[i].Accept(enc)
 	}
 
-	if g.i+1 < len(g.h.groups) {
-		g.i++
-		if len(g.suffix) != 0 || g.h.groups[g.i].i < len(g.h.fields) {
-			enc.EncodeFieldObject(g.h.groups[g.i].name, g)
+	if next := g.i + 1; next < len(g.groups) {
+		if len(g.suffix) != 0 || g.groups[next].i < len(g.fields) {
+			g.i = next
+			enc.EncodeFieldObject(g.groups[next].name, g)
+			g.i = next - 1
 		}
 	} else {
 		for i := range g.suffix {
@@ -173,6 +548,23 @@ func (g *groupEncoder) EncodeLogfObject(enc logf.FieldEncoder) error {
 	return nil
 }
 
+func (g *groupEncoder) groupAttrRange(i int) (int, int) {
+	begin := g.groups[i].i
+	end := len(g.fields)
+
+	if i+1 < len(g.groups) {
+		end = g.groups[i+1].i
+	}
+
+	return begin, end
+}
+
+// ---
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 // ---
 
 var _ slog.Handler = (*Handler)(nil)
@@ -3,11 +3,15 @@ package slogf_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/slogtest"
 	"time"
 
 	"github.com/ssgreg/logf"
@@ -22,6 +26,7 @@ func TestHandler(tt *testing.T) {
 	type test struct {
 		lineTag  LineTag
 		name     string
+		options  *slogf.HandlerOptions
 		log      func(context.Context, *slog.Logger)
 		expected []string
 	}
@@ -119,6 +124,28 @@ func TestHandler(tt *testing.T) {
 			},
 			expected: []string{`{"level":"info","msg":"test","g1":{"b":true}}`},
 		},
+		{
+			lineTag: ThisLine(),
+			name:    "WithGroup:Deep",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger = logger.With(slog.Int("a", 0))
+				for i := range 6 {
+					logger = logger.WithGroup(fmt.Sprintf("g%d", i))
+					if i%2 == 1 {
+						logger = logger.With(slog.Int("a", i))
+					}
+				}
+
+				logger.LogAttrs(ctx, slog.LevelInfo, "test 1", slog.String("key", "value"))
+				logger.LogAttrs(ctx, slog.LevelInfo, "test 2")
+				logger.WithGroup("g6").WithGroup("g7").LogAttrs(ctx, slog.LevelInfo, "test 3")
+			},
+			expected: []string{
+				`{"level":"info","msg":"test 1","a":0,"g0":{"g1":{"a":1,"g2":{"g3":{"a":3,"g4":{"g5":{"a":5,"key":"value"}}}}}}}`,
+				`{"level":"info","msg":"test 2","a":0,"g0":{"g1":{"a":1,"g2":{"g3":{"a":3,"g4":{"g5":{"a":5}}}}}}}`,
+				`{"level":"info","msg":"test 3","a":0,"g0":{"g1":{"a":1,"g2":{"g3":{"a":3,"g4":{"g5":{"a":5}}}}}}}`,
+			},
+		},
 		{
 			lineTag: ThisLine(),
 			name:    "LevelDebug",
@@ -191,6 +218,70 @@ func TestHandler(tt *testing.T) {
 			},
 			expected: []string{`{"level":"info","msg":"test","g1":{"key":42}}`},
 		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueGroupInline",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.
+					With(slog.Group("", slog.Int("a", 1))).
+					LogAttrs(ctx, slog.LevelInfo, "test", slog.Group("", slog.Int("b", 2), slog.Int("c", 3)))
+			},
+			expected: []string{`{"level":"info","msg":"test","a":1,"b":2,"c":3}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueGroupEmpty",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.With(slog.Group("g1")).LogAttrs(ctx, slog.LevelInfo, "test", slog.Int("a", 1))
+			},
+			expected: []string{`{"level":"info","msg":"test","a":1}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueGroupNestedInline",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test",
+					slog.Group("g1",
+						slog.Group("", slog.Int("a", 1)),
+						slog.Group("g2", slog.Group("", slog.Int("b", 2))),
+					),
+				)
+			},
+			expected: []string{`{"level":"info","msg":"test","g1":{"a":1,"g2":{"b":2}}}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueGroupNestedEmpty",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test",
+					slog.Group("g2", slog.Int("a", 1), slog.Group("g3", slog.Group("g4", slog.Attr{}))),
+				)
+			},
+			expected: []string{`{"level":"info","msg":"test","g2":{"a":1}}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueGroupEmptyAfterReplace",
+			options: &slogf.HandlerOptions{
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == "drop" {
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			},
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test 1", slog.Group("g1", slog.Int("drop", 1)))
+				logger.WithGroup("g2").LogAttrs(ctx, slog.LevelInfo, "test 2", slog.Int("drop", 1))
+				logger.WithGroup("g3").WithGroup("g4").LogAttrs(ctx, slog.LevelInfo, "test 3", slog.Attr{})
+			},
+			expected: []string{
+				`{"level":"info","msg":"test 1"}`,
+				`{"level":"info","msg":"test 2"}`,
+				`{"level":"info","msg":"test 3"}`,
+			},
+		},
 		{
 			lineTag: ThisLine(),
 			name:    "ValueAny",
@@ -199,6 +290,73 @@ func TestHandler(tt *testing.T) {
 			},
 			expected: []string{`{"level":"info","msg":"test","e":"err"}`},
 		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueTyped",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				tm := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+				logger.LogAttrs(ctx, slog.LevelInfo, "test",
+					slog.Any("b", []byte("abc")),
+					slog.Any("ss", []string{"a", "b"}),
+					slog.Any("is", []int{1, 2}),
+					slog.Any("t", &tm),
+					slog.Any("tn", (*time.Time)(nil)),
+					slog.Any("m", testMarshaler{"x"}),
+					slog.Any("s", struct{ X int }{42}),
+				)
+			},
+			expected: []string{
+				`{"level":"info","msg":"test","b":"YWJj","ss":["a","b"],"is":[1,2],"t":"2020-01-02T03:04:05.000000006Z",` +
+					`"tn":null,"m":{"m":"x"},"s":{"X":42}}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueCollections",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test",
+					slog.Any("a", []any{1, "s", 1.5, true, nil, []any{2}, map[string]any{"k": "v"}}),
+					slog.Any("ae", []any{}),
+					slog.Any("ma", map[string]any{"b": 1, "a": []any{"x"}, "c": map[string]int{"d": 2}}),
+					slog.Any("ms", map[string]string{"b": "2", "a": "1"}),
+					slog.Any("mi", map[string]int{"a": 1}),
+					slog.Any("mi64", map[string]int64{"a": -1}),
+					slog.Any("mf", map[string]float64{"a": 0.5}),
+					slog.Any("mb", map[string]bool{"a": true}),
+					slog.Any("me", map[string]string{}),
+				)
+			},
+			expected: []string{
+				`{"level":"info","msg":"test","a":[1,"s",1.5,true,null,[2],{"k":"v"}],"ae":[],` +
+					`"ma":{"a":["x"],"b":1,"c":{"d":2}},"ms":{"a":"1","b":"2"},"mi":{"a":1},"mi64":{"a":-1},"mf":{"a":0.5},` +
+					`"mb":{"a":true},"me":{}}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValuePanic",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test",
+					slog.Any("m", testPanicMarshaler{}),
+					slog.Any("me", testPanicMarshaler{errors.New("failure")}),
+					slog.Any("e", &testPanicError{"boom"}),
+					slog.Any("en", (*testPanicError)(nil)),
+					slog.Int("x", 1),
+				)
+			},
+			expected: []string{
+				`{"level":"info","msg":"test","m":"!PANIC: boom","me":"!ERROR:json: error calling MarshalJSON for type ` +
+					`*slogf_test.testPanicMarshaler: failure","e":"!PANIC: boom","en":"<nil>","x":1}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ValueLevel",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.Any("l", slog.LevelWarn+2))
+			},
+			expected: []string{`{"level":"info","msg":"test","l":"WARN+2"}`},
+		},
 		{
 			lineTag: ThisLine(),
 			name:    "ValueValuer",
@@ -207,17 +365,72 @@ func TestHandler(tt *testing.T) {
 			},
 			expected: []string{`{"level":"info","msg":"test","v":42}`},
 		},
+		{
+			lineTag: ThisLine(),
+			name:    "WithAttrsValuer",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.
+					With(slog.Any("a", testValuer{1}), slog.Group("g1", slog.Any("b", testValuer{2}), slog.Int("c", 3))).
+					WithGroup("g2").
+					With(slog.Any("d", testGroupValuer{slog.Any("e", testValuer{4})}), slog.Any("f", testGroupValuer{})).
+					LogAttrs(ctx, slog.LevelInfo, "test", slog.Any("h", testGroupValuer{slog.Any("i", testValuer{5})}))
+			},
+			expected: []string{`{"level":"info","msg":"test","a":1,"g1":{"b":2,"c":3},"g2":{"d":{"e":4},"h":{"i":5}}}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "WithAttrsValuerEmpty",
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.
+					WithGroup("g1").
+					With(slog.Any("a", testGroupValuer{})).
+					WithGroup("g2").
+					With(slog.Any("", testGroupValuer{slog.Int("b", 1)})).
+					LogAttrs(ctx, slog.LevelInfo, "test")
+			},
+			expected: []string{`{"level":"info","msg":"test","g1":{"g2":{"b":1}}}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "ReplaceAttr",
+			options: &slogf.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					switch a.Key {
+					case "a":
+						a.Key = "A"
+					case "k":
+						a.Value = slog.StringValue(strings.Join(groups, "."))
+					case "secret":
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			},
+			log: func(ctx context.Context, logger *slog.Logger) {
+				logger.
+					With(slog.String("a", "x"), slog.String("secret", "s1")).
+					WithGroup("g1").
+					With(slog.String("k", "")).
+					LogAttrs(ctx, slog.LevelInfo, "test",
+						slog.String("secret", "s2"),
+						slog.Group("g2", slog.String("k", ""), slog.Int("a", 1)),
+						slog.Any("v", testValuer{42}),
+					)
+			},
+			expected: []string{`{"level":"info","msg":"test","A":"x","g1":{"k":"g1","g2":{"k":"g1.g2","A":1},"v":42}}`},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t Test) {
 			t.Run("slog", func(t Test) {
 				t.AddLineTags(test.lineTag)
-				t.Expect(testLog(testSlog(test.log))).To(Equal(test.expected))
+				t.Expect(testLog(testSlog(test.log, test.options))).To(Equal(test.expected))
 			})
 			t.Run("slogf", func(t Test) {
 				t.AddLineTags(test.lineTag)
-				t.Expect(testLog(testSlogf(test.log))).To(Equal(test.expected))
+				t.Expect(testLog(testSlogf(test.log, test.options))).To(Equal(test.expected))
 			})
 		})
 	}
@@ -230,6 +443,509 @@ func TestHandler(tt *testing.T) {
 			})),
 		).To(Equal([]string{`{"level":"info","msg":"test","key":"value"}`}))
 	})
+
+	// These cases are not compared with slog.JSONHandler because it produces invalid JSON for them.
+	t.Run("EmptyGroupFirst", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger))
+				logger.With(slog.Group("g1", slog.Attr{}), slog.Group("g2", slog.Group("", slog.Attr{}))).Info("test 1")
+				logger.Info("test 2", slog.Group("g1", slog.Attr{}), slog.Group("g2", slog.Group("", slog.Attr{})), slog.Int("a", 1))
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test 1"}`,
+			`{"level":"info","msg":"test 2","a":1}`,
+		}))
+	})
+
+	t.Run("WithAttrsValuerLazy", func(t Test) {
+		var calls int
+
+		valuer := testFuncValuer(func() slog.Value {
+			calls++
+
+			return slog.IntValue(calls)
+		})
+
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger.WithLevel(logf.LevelInfo)))
+				logger = logger.With(slog.Group("g", slog.Any("calls", valuer)))
+				logger.Debug("test 1")
+				t.Expect(calls).To(Equal(0))
+				logger.Info("test 2")
+				logger.Info("test 3")
+				logger.With(slog.Int("a", 1)).Info("test 4")
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test 2","g":{"calls":1}}`,
+			`{"level":"info","msg":"test 3","g":{"calls":1}}`,
+			`{"level":"info","msg":"test 4","g":{"calls":1},"a":1}`,
+		}))
+		t.Expect(calls).To(Equal(1))
+	})
+
+	// This case is not compared with slog.JSONHandler because it marshals fmt.Stringer values as JSON.
+	t.Run("ValueStringer", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger))
+				logger.Info("test", slog.Any("s", testStringer{42}), slog.Any("n", fmt.Stringer(nil)))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","s":"stringer 42","n":null}`}))
+	})
+
+	// This case is not compared with slog.JSONHandler because it does not call logf encoders.
+	t.Run("ValueEncoderStringer", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger))
+				logger.Info("test", slog.Any("o", testObjectStringer{}), slog.Any("a", testArrayStringer{}))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","o":{"x":1,"y":"s"},"a":[1,"s"]}`}))
+	})
+
+	t.Run("WithValueConverters", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithValueConverters(
+					slogf.ConvertValue(func(key string, value testStringer) logf.Field {
+						return logf.Int(key, value.value)
+					}),
+					slogf.ConvertValue(func(key string, _ []byte) logf.Field {
+						return logf.String(key, "replaced")
+					}),
+				).WithValueConverters(
+					slogf.ConvertValue(func(key string, value []byte) logf.Field {
+						return logf.String(key, string(value))
+					}),
+					slogf.ConvertObject(func(testMarshaler) logf.ObjectEncoder {
+						return testObject{}
+					}),
+				)
+
+				logger := slog.New(handler).With(slog.Any("s", testStringer{1})).WithGroup("g")
+				logger.Info("test", slog.Any("b", []byte("abc")), slog.Group("h", slog.Any("m", testMarshaler{"x"})), slog.Any("e", errors.New("e")))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","s":1,"g":{"b":"abc","h":{"m":{"x":1,"y":"s"}},"e":"e"}}`}))
+	})
+
+	t.Run("WithValueConvertersInterface", func(t Test) {
+		panicked := func(f func()) (panicked bool) {
+			defer func() {
+				panicked = recover() != nil
+			}()
+
+			f()
+
+			return false
+		}
+
+		t.Expect(panicked(func() {
+			slogf.ConvertValue(func(key string, value fmt.Stringer) logf.Field {
+				return logf.String(key, value.String())
+			})
+		})).To(BeTrue())
+		t.Expect(panicked(func() {
+			slogf.ConvertObject(func(error) logf.ObjectEncoder {
+				return testObject{}
+			})
+		})).To(BeTrue())
+		t.Expect(panicked(func() {
+			slogf.ConvertValue(func(key string, value testStringer) logf.Field {
+				return logf.Int(key, value.value)
+			})
+		})).To(BeFalse())
+	})
+
+	t.Run("WithValueConvertersNilEncoders", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithValueConverters(
+					slogf.ConvertValue(func(key string, _ testStringer) logf.Field {
+						return logf.Field{Key: key, Type: logf.FieldTypeObject}
+					}),
+					slogf.ConvertValue(func(key string, _ testMarshaler) logf.Field {
+						return logf.Field{Key: key, Type: logf.FieldTypeArray}
+					}),
+				)
+				slog.New(handler).Info("test", slog.Any("o", testStringer{1}), slog.Any("a", testMarshaler{"x"}))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","o":"nil","a":"nil"}`}))
+	})
+
+	// This case is not compared with slog.JSONHandler because it marshals slog.Value as an empty object.
+	t.Run("ValueSlogValues", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger))
+				logger.Info("test",
+					slog.Any("a", []slog.Value{slog.IntValue(1), slog.GroupValue(slog.String("k", "v")), slog.AnyValue(testValuer{2})}),
+					slog.Any("m", map[string]slog.Value{"b": slog.StringValue("x"), "a": slog.AnyValue([]slog.Attr{slog.Int("c", 3)})}),
+				)
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","a":[1,{"k":"v"},2],"m":{"a":{"c":3},"b":"x"}}`}))
+	})
+
+	// This case is not compared with slog.JSONHandler because it does not call String and logf encoders.
+	t.Run("ValuePanicSlogf", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithValueConverters(
+					slogf.ConvertValue(func(string, testObject) logf.Field {
+						panic("converter")
+					}),
+				)
+				slog.New(handler).Info("test",
+					slog.Any("s", testPanicStringer{}),
+					slog.Any("o", testPanicObject{}),
+					slog.Any("c", testObject{}),
+					slog.Any("a", []any{1, testPanicMarshaler{}, testObject{}}),
+					slog.Int("x", 1),
+				)
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","s":"!PANIC: stringer","o":{"x":1,"!PANIC":"!PANIC: object"},` +
+			`"c":"!PANIC: converter","a":[1,"!PANIC: boom","!PANIC: converter"],"x":1}`}))
+	})
+
+	// This case is not compared with slog.JSONHandler because it encodes elements of slices using encoding/json.
+	t.Run("ValueCollectionsSlogf", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithDurationFormat(slogf.DurationString).
+					WithErrorEncoder(slogf.EncodeError(func(key string, err error) logf.Field {
+						return logf.String(key, "error: "+err.Error())
+					}))
+				slog.New(handler).Info("test",
+					slog.Any("a", []any{errors.New("boom"), struct{ A int }{1}, time.Second, nil, testMarshaler{"x"}}),
+					slog.Any("b", []any{errors.New("boom"), time.Second}),
+					slog.Any("c", []any{nil, []any{1, struct{}{}, nil}, map[string]any{"b": nil, "a": []int{1}}}),
+				)
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","a":["error: boom",{"A":1},"1s",null,{"m":"x"}],"b":["error: boom","1s"],` +
+			`"c":[null,[1,{},null],{"a":[1],"b":null}]}`}))
+	})
+
+	t.Run("Level", func(t Test) {
+		var level slog.LevelVar
+		level.Set(slog.LevelWarn)
+
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandlerWithOptions(&slogf.HandlerOptions{Level: &level}).WithLogger(logfLogger)
+				logger := slog.New(handler.WithGroup("g"))
+				logger.Info("test 1")
+				logger.Warn("test 2")
+				level.Set(slog.LevelDebug)
+				logger.Info("test 3")
+				logger.Debug("test 4")
+				_ = handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelDebug-1, "test 5", 0))
+			})),
+		).To(Equal([]string{
+			`{"level":"warn","msg":"test 2"}`,
+			`{"level":"info","msg":"test 3"}`,
+			`{"level":"debug","msg":"test 4"}`,
+		}))
+	})
+
+	t.Run("LevelWithLogfLevel", func(t Test) {
+		appender := &testAppender{}
+		logfLogger := logf.NewLogger(logf.LevelInfo, logf.NewUnbufferedEntryWriter(appender))
+		handler := slogf.NewHandlerWithOptions(&slogf.HandlerOptions{Level: slog.LevelDebug}).WithLogger(logfLogger)
+
+		t.Expect(handler.Enabled(context.Background(), slog.LevelDebug)).To(BeFalse())
+		t.Expect(handler.Enabled(context.Background(), slog.LevelInfo)).To(BeTrue())
+	})
+
+	t.Run("WithLevelKey", func(t Test) {
+		const (
+			levelTrace    = slog.Level(-8)
+			levelNotice   = slog.Level(2)
+			levelCritical = slog.Level(12)
+		)
+
+		options := &slogf.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == "severity" {
+					switch a.Value.Any().(slog.Level) {
+					case levelNotice:
+						a.Value = slog.StringValue("NOTICE")
+					case levelCritical:
+						a.Value = slog.StringValue("CRITICAL")
+					}
+				}
+
+				return a
+			},
+		}
+
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandlerWithOptions(options).WithLogger(logfLogger).WithLevelKey("severity")
+				logger := slog.New(handler.WithGroup("g"))
+				logger.Log(context.Background(), levelTrace, "test 1")
+				logger.Log(context.Background(), levelNotice, "test 2", slog.Int("a", 1))
+				logger.Log(context.Background(), slog.LevelInfo+1, "test 3")
+				logger.Log(context.Background(), levelCritical, "test 4")
+			})),
+		).To(Equal([]string{
+			`{"level":"debug","msg":"test 1","severity":"DEBUG-4"}`,
+			`{"level":"info","msg":"test 2","severity":"NOTICE","g":{"a":1}}`,
+			`{"level":"info","msg":"test 3","severity":"INFO+1"}`,
+			`{"level":"error","msg":"test 4","severity":"CRITICAL"}`,
+		}))
+	})
+
+	t.Run("WithCaller", func(t Test) {
+		var caller logf.EntryCaller
+
+		lines := testLog(testLogf(func(logfLogger *logf.Logger) {
+			logger := slog.New(slogf.NewHandler().WithLogger(logfLogger.WithCaller()))
+			_, caller.File, caller.Line, _ = runtime.Caller(0)
+			logger.Info("test")
+		}))
+
+		expected := fmt.Sprintf(`{"level":"info","msg":"test","caller":"%s:%d"}`, caller.FileWithPackage(), caller.Line+1)
+		t.Expect(lines).To(Equal([]string{expected}))
+	})
+
+	t.Run("WithTimeKey", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger).WithTimeKey("time").WithGroup("g"))
+				ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+				record := slog.NewRecord(ts, slog.LevelInfo, "test 1", 0)
+				record.AddAttrs(slog.Int("a", 1))
+				_ = logger.Handler().Handle(context.Background(), record)
+				_ = logger.Handler().Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "test 2", 0))
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test 1","time":"2020-01-02T03:04:05.000000006Z","g":{"a":1}}`,
+			`{"level":"info","msg":"test 2"}`,
+		}))
+	})
+
+	t.Run("RecordTime", func(t Test) {
+		appender := &testAppender{}
+		handler := slogf.NewHandler().WithLogger(logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender)))
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+		_ = handler.Handle(context.Background(), slog.NewRecord(ts, slog.LevelInfo, "test 1", 0))
+		_ = handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "test 2", 0))
+
+		t.Expect(appender.entries).To(HaveLen(2))
+		t.Expect(appender.entries[0].Time).To(Equal(ts))
+		t.Expect(appender.entries[1].Time.IsZero()).To(BeTrue())
+	})
+
+	t.Run("WithAttrsDerivedLogger", func(t Test) {
+		appender := &testAppender{}
+		handler := slogf.NewHandler().WithLogger(logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender)))
+		logger := slog.New(handler).With("a", 1)
+		logger.Info("test 1")
+		logger.Info("test 2", "b", 2)
+		logger.WithGroup("g").Info("test 3", "c", 3)
+
+		t.Expect(appender.entries).To(HaveLen(3))
+		t.Expect(appender.entries[0].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+		t.Expect(appender.entries[1].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+		t.Expect(appender.entries[1].LoggerID).To(Equal(appender.entries[0].LoggerID))
+		t.Expect(appender.entries[2].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+		t.Expect(appender.entries[2].Fields).To(HaveLen(1))
+	})
+
+	t.Run("WithAttrsLoggerPerContext", func(t Test) {
+		var buf1, buf2 bytes.Buffer
+
+		encoder := logf.NewJSONEncoder(logf.JSONEncoderConfig{DisableFieldTime: true, EncodeTime: logf.RFC3339NanoTimeEncoder})
+		appender1 := logf.NewWriteAppender(&buf1, encoder)
+		appender2 := logf.NewWriteAppender(&buf2, encoder)
+		logger1 := logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender1)).With(logf.String("l", "1"))
+		logger2 := logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender2))
+		ctx1 := logf.NewContext(context.Background(), logger1)
+		ctx2 := logf.NewContext(context.Background(), logger2)
+		logger := slog.New(slogf.NewHandler().WithTimeKey("time")).With("a", 1)
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+		for _, ctx := range []context.Context{ctx1, ctx2, ctx1} {
+			record := slog.NewRecord(ts, slog.LevelInfo, "test", 0)
+			record.AddAttrs(slog.Int("b", 2))
+			_ = logger.Handler().Handle(ctx, record)
+		}
+
+		_ = appender1.Flush()
+		_ = appender2.Flush()
+
+		t.Expect(strings.Split(strings.TrimSpace(buf1.String()), "\n")).To(Equal([]string{
+			`{"level":"info","msg":"test","l":"1","time":"2020-01-02T03:04:05.000000006Z","a":1,"b":2}`,
+			`{"level":"info","msg":"test","l":"1","time":"2020-01-02T03:04:05.000000006Z","a":1,"b":2}`,
+		}))
+		t.Expect(strings.Split(strings.TrimSpace(buf2.String()), "\n")).To(Equal([]string{
+			`{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","a":1,"b":2}`,
+		}))
+	})
+
+	t.Run("UnsupportedLoggerLayout", func(t Test) {
+		defer slogf.SetLoggerLayoutError(errors.New("unsupported"))()
+
+		appender := &testAppender{}
+		logfLogger := logf.NewLogger(logf.LevelInfo, logf.NewUnbufferedEntryWriter(appender)).WithCaller()
+		logger := slog.New(slogf.NewHandler().WithLogger(logfLogger)).With("a", 1)
+		ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+		record := slog.NewRecord(ts, slog.LevelInfo, "test", 0)
+		record.AddAttrs(slog.Int("b", 2))
+		_ = logger.Handler().Handle(context.Background(), record)
+		logger.Debug("test 2")
+		logger.Warn("test 3")
+
+		t.Expect(logger.Enabled(context.Background(), slog.LevelDebug)).To(BeFalse())
+		t.Expect(logger.Enabled(context.Background(), slog.LevelInfo)).To(BeTrue())
+		t.Expect(appender.entries).To(HaveLen(2))
+		t.Expect(appender.entries[0].Text).To(Equal("test"))
+		t.Expect(appender.entries[0].Level).To(Equal(logf.LevelInfo))
+		t.Expect(appender.entries[0].Time).To(Not(Equal(ts)))
+		t.Expect(appender.entries[0].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+		t.Expect(appender.entries[0].Fields).To(Equal([]logf.Field{logf.Int64("b", 2)}))
+		t.Expect(appender.entries[1].Text).To(Equal("test 3"))
+		t.Expect(appender.entries[1].Level).To(Equal(logf.LevelWarn))
+	})
+
+	t.Run("WithAttrsDerivedContextLogger", func(t Test) {
+		appender1, appender2 := &testAppender{}, &testAppender{}
+		ctx1 := logf.NewContext(context.Background(), logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender1)))
+		ctx2 := logf.NewContext(context.Background(), logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender2)))
+		logger := slog.New(slogf.NewHandler()).With("a", 1)
+
+		for _, ctx := range []context.Context{ctx1, ctx2, ctx1, ctx2, ctx1} {
+			logger.InfoContext(ctx, "test", "b", 2)
+		}
+
+		t.Expect(appender1.entries).To(HaveLen(3))
+		t.Expect(appender1.entries[0].DerivedFields).To(HaveLen(0))
+		t.Expect(appender1.entries[0].Fields).To(Equal([]logf.Field{logf.Int64("a", 1), logf.Int64("b", 2)}))
+		t.Expect(appender1.entries[1].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+		t.Expect(appender1.entries[1].Fields).To(Equal([]logf.Field{logf.Int64("b", 2)}))
+		t.Expect(appender1.entries[2].LoggerID).To(Equal(appender1.entries[1].LoggerID))
+		t.Expect(appender2.entries).To(HaveLen(2))
+		t.Expect(appender2.entries[0].DerivedFields).To(HaveLen(0))
+		t.Expect(appender2.entries[1].DerivedFields).To(Equal([]logf.Field{logf.Int64("a", 1)}))
+
+		appender1.entries = nil
+		logger = slog.New(slogf.NewHandler().WithLevelKey("slog")).With("a", 1)
+		logger.InfoContext(ctx1, "test")
+		logger.InfoContext(ctx1, "test")
+
+		t.Expect(appender1.entries).To(HaveLen(2))
+		t.Expect(appender1.entries[1].DerivedFields).To(HaveLen(0))
+		t.Expect(appender1.entries[1].Fields).To(HaveLen(2))
+	})
+
+	t.Run("RetainingAppender", func(t Test) {
+		appender := &testAppender{}
+		handler := slogf.NewHandler().WithLogger(logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender)))
+		logger := slog.New(handler).WithGroup("g")
+		logger.Info("test 1", "a", 1)
+		logger.Info("test 2", "b", 2)
+
+		t.Expect(appender.entries).To(HaveLen(2))
+		t.Expect(appender.entries[0].Fields).To(HaveLen(1))
+		t.Expect(appender.entries[0].Fields[0].Key).To(Equal("g"))
+		t.Expect(appender.entries[1].Fields).To(HaveLen(1))
+		t.Expect(appender.entries[1].Fields[0].Key).To(Equal("g"))
+	})
+
+	t.Run("WithBufferReuse", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger).WithTimeKey("time").WithBufferReuse(true)
+				ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+				for i, h := range []slog.Handler{handler, handler.WithGroup("g").WithAttrs([]slog.Attr{slog.Int("a", 1)}), handler} {
+					record := slog.NewRecord(ts, slog.LevelInfo, "test", 0)
+					record.AddAttrs(slog.Int("i", i))
+					_ = h.Handle(context.Background(), record)
+				}
+
+				_ = handler.WithBufferReuse(false).Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "test", 0))
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","i":0}`,
+			`{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","g":{"a":1,"i":1}}`,
+			`{"level":"info","msg":"test","time":"2020-01-02T03:04:05.000000006Z","i":2}`,
+			`{"level":"info","msg":"test"}`,
+		}))
+	})
+
+	t.Run("ChannelWriter", func(t Test) {
+		t.Expect(
+			testLog(func(writer io.Writer) {
+				appender := logf.NewWriteAppender(writer, logf.NewJSONEncoder(logf.JSONEncoderConfig{DisableFieldTime: true}))
+				entryWriter, closeWriter := logf.NewChannelWriter(logf.ChannelWriterConfig{Capacity: 16, Appender: appender})
+				handler := slogf.NewHandler().WithLogger(logf.NewLogger(logf.LevelDebug, entryWriter))
+				logger := slog.New(handler).With("a", 1).WithGroup("g").With("b", 2)
+
+				for i := range 3 {
+					logger.Info("test", "i", i)
+				}
+
+				slog.New(handler).Info("test", "j", 3)
+				closeWriter()
+			}),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test","a":1,"g":{"b":2,"i":0}}`,
+			`{"level":"info","msg":"test","a":1,"g":{"b":2,"i":1}}`,
+			`{"level":"info","msg":"test","a":1,"g":{"b":2,"i":2}}`,
+			`{"level":"info","msg":"test","j":3}`,
+		}))
+	})
+
+	t.Run("WithCallerNoPC", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger.WithCaller())
+				err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "test", 0))
+				if err != nil {
+					panic(err)
+				}
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test"}`}))
+	})
+}
+
+func TestHandlerConformance(tt *testing.T) {
+	t := New(tt)
+
+	buffer := bytes.NewBuffer(nil)
+	appender := logf.NewWriteAppender(buffer, logf.NewJSONEncoder(logf.JSONEncoderConfig{
+		DisableFieldTime: true,
+	}))
+
+	logger := logf.NewLogger(logf.LevelInfo, logf.NewUnbufferedEntryWriter(appender))
+	handler := slogf.NewHandler().WithLogger(logger).WithTimeKey(slog.TimeKey)
+
+	results := func() []map[string]any {
+		err := appender.Flush()
+		if err != nil {
+			panic(err)
+		}
+
+		var result []map[string]any
+
+		for _, line := range bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte{'\n'}) {
+			var m map[string]any
+
+			err := json.Unmarshal(line, &m)
+			if err != nil {
+				panic(err)
+			}
+
+			result = append(result, m)
+		}
+
+		return result
+	}
+
+	t.Expect(slogtest.TestHandler(handler, results)).ToSucceed()
 }
 
 func testLog(f func(io.Writer)) []string {
@@ -239,9 +955,9 @@ func testLog(f func(io.Writer)) []string {
 	return strings.Split(strings.TrimSpace(buffer.String()), "\n")
 }
 
-func testSlogf(f func(context.Context, *slog.Logger)) func(io.Writer) {
+func testSlogf(f func(context.Context, *slog.Logger), options *slogf.HandlerOptions) func(io.Writer) {
 	return func(writer io.Writer) {
-		handler := slogf.NewHandler()
+		handler := slogf.NewHandlerWithOptions(options)
 		appender := logf.NewWriteAppender(writer, logf.NewJSONEncoder(logf.JSONEncoderConfig{
 			DisableFieldTime: true,
 			EncodeDuration:   logf.NanoDurationEncoder,
@@ -266,7 +982,11 @@ func testSlogf(f func(context.Context, *slog.Logger)) func(io.Writer) {
 	}
 }
 
-func testSlog(f func(context.Context, *slog.Logger)) func(io.Writer) {
+func testSlog(f func(context.Context, *slog.Logger), testOptions *slogf.HandlerOptions) func(io.Writer) {
+	if testOptions == nil {
+		testOptions = &slogf.HandlerOptions{}
+	}
+
 	options := &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
@@ -278,6 +998,14 @@ func testSlog(f func(context.Context, *slog.Logger)) func(io.Writer) {
 				return slog.Attr{Key: "level", Value: slog.StringValue(strings.ToLower(a.Value.String()))}
 			}
 
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				return a
+			}
+
+			if testOptions.ReplaceAttr != nil {
+				return testOptions.ReplaceAttr(groups, a)
+			}
+
 			return a
 		},
 	}
@@ -313,6 +1041,26 @@ func testLogf(f func(*logf.Logger)) func(io.Writer) {
 
 // ---
 
+type testAppender struct {
+	entries []logf.Entry
+}
+
+func (a *testAppender) Append(entry logf.Entry) error {
+	a.entries = append(a.entries, entry)
+
+	return nil
+}
+
+func (a *testAppender) Flush() error {
+	return nil
+}
+
+func (a *testAppender) Sync() error {
+	return nil
+}
+
+// ---
+
 type testValuer struct {
 	value int
 }
@@ -323,4 +1071,123 @@ func (v testValuer) LogValue() slog.Value {
 
 // ---
 
-var _ slog.LogValuer = testValuer{}
+type testGroupValuer []slog.Attr
+
+func (v testGroupValuer) LogValue() slog.Value {
+	return slog.GroupValue(v...)
+}
+
+// ---
+
+type testMarshaler struct {
+	value string
+}
+
+func (m testMarshaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"m": m.value})
+}
+
+func (m testMarshaler) Error() string {
+	return "error " + m.value
+}
+
+func (m testMarshaler) String() string {
+	return "stringer " + m.value
+}
+
+// ---
+
+type testStringer struct {
+	value int
+}
+
+func (s testStringer) String() string {
+	return fmt.Sprintf("stringer %d", s.value)
+}
+
+// ---
+
+type testObjectStringer struct {
+	testObject
+}
+
+func (testObjectStringer) String() string {
+	return "object stringer"
+}
+
+// ---
+
+type testArrayStringer struct {
+	testArrayFlat
+}
+
+func (testArrayStringer) String() string {
+	return "array stringer"
+}
+
+// ---
+
+type testPanicMarshaler struct {
+	err error
+}
+
+func (m testPanicMarshaler) MarshalJSON() ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	panic("boom")
+}
+
+// ---
+
+type testPanicError struct {
+	msg string
+}
+
+func (e *testPanicError) Error() string {
+	panic(e.msg)
+}
+
+// ---
+
+type testPanicStringer struct{}
+
+func (testPanicStringer) String() string {
+	panic("stringer")
+}
+
+// ---
+
+type testPanicObject struct{}
+
+func (testPanicObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	enc.EncodeFieldInt64("x", 1)
+	panic("object")
+}
+
+// ---
+
+type testFuncValuer func() slog.Value
+
+func (v testFuncValuer) LogValue() slog.Value {
+	return v()
+}
+
+// ---
+
+var (
+	_ logf.Appender  = (*testAppender)(nil)
+	_ slog.LogValuer = testValuer{}
+	_ slog.LogValuer = testGroupValuer{}
+	_ slog.LogValuer = testFuncValuer(nil)
+	_ json.Marshaler = testMarshaler{}
+	_ error          = testMarshaler{}
+	_ fmt.Stringer   = testMarshaler{}
+	_ fmt.Stringer   = testStringer{}
+	_ json.Marshaler = testPanicMarshaler{}
+	_ error          = (*testPanicError)(nil)
+	_ fmt.Stringer   = testPanicStringer{}
+
+	_ logf.ObjectEncoder = testPanicObject{}
+)
@@ -6,29 +6,66 @@ import (
 	"github.com/ssgreg/logf"
 )
 
-// LogfLevel converts slog.Level to logf.Level.
+// LogfLevel converts slog.Level to logf.Level using the default level mapping.
 func LogfLevel(level slog.Level) logf.Level {
+	return DefaultLevelMapping().LogfLevel(level)
+}
+
+// SlogLevel converts logf.Level to slog.Level using the default level mapping.
+func SlogLevel(level logf.Level) slog.Level {
+	return DefaultLevelMapping().SlogLevel(level)
+}
+
+// ---
+
+// DefaultLevelMapping returns the level mapping which maps logf levels to the slog levels with the same names.
+func DefaultLevelMapping() LevelMapping {
+	return LevelMapping{
+		Debug: slog.LevelDebug,
+		Info:  slog.LevelInfo,
+		Warn:  slog.LevelWarn,
+		Error: slog.LevelError,
+	}
+}
+
+// LevelMapping defines conversion between slog and logf levels in both directions.
+// Each field specifies the slog level corresponding to the logf level with the same name.
+// Fields are expected to be in ascending order, so that Debug < Info < Warn < Error.
+//
+// A slog level is converted to the highest logf level which corresponding slog level does not exceed it,
+// or to logf.LevelDebug if there is no such level.
+// A logf level is converted to its corresponding slog level, so converting it back gives the same logf level.
+type LevelMapping struct {
+	Debug slog.Level
+	Info  slog.Level
+	Warn  slog.Level
+	Error slog.Level
+}
+
+// LogfLevel converts slog.Level to logf.Level.
+func (m LevelMapping) LogfLevel(level slog.Level) logf.Level {
 	switch {
-	case level >= slog.LevelError:
+	case level >= m.Error:
 		return logf.LevelError
-	case level >= slog.LevelWarn:
+	case level >= m.Warn:
 		return logf.LevelWarn
-	case level >= slog.LevelInfo:
+	case level >= m.Info:
 		return logf.LevelInfo
 	default:
 		return logf.LevelDebug
 	}
 }
 
-func logfLog(level slog.Level, logger *logf.Logger, text string, fields ...logf.Field) {
+// SlogLevel converts logf.Level to slog.Level.
+func (m LevelMapping) SlogLevel(level logf.Level) slog.Level {
 	switch {
-	case level >= slog.LevelError:
-		logger.Error(text, fields...)
-	case level >= slog.LevelWarn:
-		logger.Warn(text, fields...)
-	case level >= slog.LevelInfo:
-		logger.Info(text, fields...)
+	case level <= logf.LevelError:
+		return m.Error
+	case level == logf.LevelWarn:
+		return m.Warn
+	case level == logf.LevelInfo:
+		return m.Info
 	default:
-		logger.Debug(text, fields...)
+		return m.Debug
 	}
 }
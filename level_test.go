@@ -0,0 +1,85 @@
+package slogf_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestLevel(tt *testing.T) {
+	t := New(tt)
+
+	t.Run("Default", func(t Test) {
+		t.Expect(slogf.LogfLevel(slog.LevelDebug - 4)).To(Equal(logf.LevelDebug))
+		t.Expect(slogf.LogfLevel(slog.LevelDebug)).To(Equal(logf.LevelDebug))
+		t.Expect(slogf.LogfLevel(slog.LevelInfo - 1)).To(Equal(logf.LevelDebug))
+		t.Expect(slogf.LogfLevel(slog.LevelInfo)).To(Equal(logf.LevelInfo))
+		t.Expect(slogf.LogfLevel(slog.LevelWarn - 1)).To(Equal(logf.LevelInfo))
+		t.Expect(slogf.LogfLevel(slog.LevelWarn)).To(Equal(logf.LevelWarn))
+		t.Expect(slogf.LogfLevel(slog.LevelError)).To(Equal(logf.LevelError))
+		t.Expect(slogf.LogfLevel(slog.LevelError + 4)).To(Equal(logf.LevelError))
+
+		t.Expect(slogf.SlogLevel(logf.LevelDebug)).To(Equal(slog.LevelDebug))
+		t.Expect(slogf.SlogLevel(logf.LevelInfo)).To(Equal(slog.LevelInfo))
+		t.Expect(slogf.SlogLevel(logf.LevelWarn)).To(Equal(slog.LevelWarn))
+		t.Expect(slogf.SlogLevel(logf.LevelError)).To(Equal(slog.LevelError))
+	})
+
+	t.Run("RoundTrip", func(t Test) {
+		mapping := slogf.LevelMapping{Debug: -8, Info: -2, Warn: 3, Error: 8}
+
+		for _, level := range []logf.Level{logf.LevelDebug, logf.LevelInfo, logf.LevelWarn, logf.LevelError} {
+			t.Expect(slogf.LogfLevel(slogf.SlogLevel(level))).To(Equal(level))
+			t.Expect(mapping.LogfLevel(mapping.SlogLevel(level))).To(Equal(level))
+		}
+	})
+
+	t.Run("Custom", func(t Test) {
+		mapping := slogf.LevelMapping{Debug: -8, Info: -2, Warn: 3, Error: 8}
+
+		t.Expect(mapping.LogfLevel(-3)).To(Equal(logf.LevelDebug))
+		t.Expect(mapping.LogfLevel(-2)).To(Equal(logf.LevelInfo))
+		t.Expect(mapping.LogfLevel(slog.LevelInfo)).To(Equal(logf.LevelInfo))
+		t.Expect(mapping.LogfLevel(slog.LevelWarn)).To(Equal(logf.LevelWarn))
+		t.Expect(mapping.LogfLevel(slog.LevelError - 1)).To(Equal(logf.LevelWarn))
+		t.Expect(mapping.LogfLevel(slog.LevelError)).To(Equal(logf.LevelError))
+		t.Expect(mapping.SlogLevel(logf.LevelInfo)).To(Equal(slog.Level(-2)))
+	})
+
+	t.Run("Handler", func(t Test) {
+		mapping := slogf.LevelMapping{Debug: -8, Info: -2, Warn: 3, Error: 8}
+
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				logger := slog.New(slogf.NewHandler().WithLogger(logfLogger).WithLevelMapping(mapping))
+				logger.Log(context.Background(), -2, "test 1")
+				logger.Log(context.Background(), slog.LevelError-1, "test 2")
+			})),
+		).To(Equal([]string{
+			`{"level":"info","msg":"test 1"}`,
+			`{"level":"warn","msg":"test 2"}`,
+		}))
+	})
+
+	t.Run("EntryWriter", func(t Test) {
+		mapping := slogf.LevelMapping{Debug: -8, Info: -2, Warn: 3, Error: 8}
+
+		var levels []slog.Level
+		handler := testHandler(func(record slog.Record) {
+			levels = append(levels, record.Level)
+		})
+
+		logger := logf.NewLogger(logf.LevelDebug, slogf.NewEntryWriter(handler).WithLevelMapping(mapping))
+		logger.Debug("test")
+		logger.Info("test")
+		logger.Warn("test")
+		logger.Error("test")
+
+		t.Expect(levels).To(Equal([]slog.Level{-8, -2, 3, 8}))
+	})
+}
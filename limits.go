@@ -0,0 +1,122 @@
+package slogf
+
+import (
+	"encoding/base64"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DefaultMaxDepth is the maximum nesting depth of attribute values used if Limits.MaxDepth is not set.
+const DefaultMaxDepth = 32
+
+// DefaultMaxNodes is the maximum number of converted values per record used if Limits.MaxNodes is not set.
+const DefaultMaxNodes = 10000
+
+// Limits limits the size of converted attribute values,
+// so that a buggy slog.LogValuer or a huge value cannot blow up log size or CPU usage.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of groups, slices and maps within an attribute value,
+	// including groups returned by slog.LogValuer values.
+	// A group, slice or map nested deeper is replaced with the "!MAXDEPTH" string,
+	// except for a group with an empty key, which is omitted because it is inlined into its parent.
+	// Groups opened by WithGroup are not counted.
+	// If MaxDepth is zero or negative, DefaultMaxDepth is used, so a chain of self-referential values always ends.
+	// Values referencing themselves more than once are also limited by MaxNodes.
+	MaxDepth int
+
+	// MaxNodes is the maximum total number of attributes, group members, slice elements and map entries
+	// converted for a record, including nested ones and the ones returned by slog.LogValuer values.
+	// The first attribute or element exceeding the limit is replaced with the "!MAXNODES" string,
+	// unless it has an empty key, and the following ones are omitted.
+	// Attributes added by a WithAttrs call are limited separately from the record attributes.
+	// If MaxNodes is zero or negative, DefaultMaxNodes is used.
+	MaxNodes int
+
+	// MaxAttrs is the maximum number of attributes in a group, including groups returned by slog.LogValuer values.
+	// Extra attributes are omitted and the "!TRUNCATED" field with the number of omitted attributes is added instead.
+	// If MaxAttrs is zero or negative, the number of attributes is not limited.
+	MaxAttrs int
+
+	// MaxStringLength is the maximum length in bytes of string values, including results of String methods.
+	// Longer strings are cut at a UTF-8 character boundary and the "...!TRUNCATED:N" suffix is added,
+	// where N is the number of omitted bytes.
+	// If MaxStringLength is zero or negative, the length of strings is not limited.
+	MaxStringLength int
+
+	// MaxBytesLength is the maximum length of []byte values.
+	// Longer values are cut and represented as a string with the base64 encoding of the remaining bytes
+	// followed by the "...!TRUNCATED:N" suffix, where N is the number of omitted bytes.
+	// If MaxBytesLength is zero or negative, the length of byte slices is not limited.
+	MaxBytesLength int
+
+	// MaxElements is the maximum number of elements in slices and maps converted to logf arrays and objects,
+	// like []any, []slog.Value, []string, []int and maps with string keys.
+	// Extra elements of a slice are omitted and the "...!TRUNCATED:N" string element is added instead,
+	// where N is the number of omitted elements.
+	// Extra entries of a map are omitted after sorting by key and the "!TRUNCATED" field
+	// with the number of omitted entries is added instead.
+	// If MaxElements is zero or negative, the number of elements is not limited.
+	MaxElements int
+}
+
+func (l Limits) maxDepth() int {
+	if l.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+
+	return l.MaxDepth
+}
+
+func (l Limits) maxNodes() int {
+	if l.MaxNodes <= 0 {
+		return DefaultMaxNodes
+	}
+
+	return l.MaxNodes
+}
+
+func (l Limits) truncateString(s string) string {
+	n := limited(len(s), l.MaxStringLength)
+	if n == len(s) {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + truncatedSuffix(len(s)-n)
+}
+
+func (l Limits) truncateBytes(b []byte) (string, bool) {
+	n := limited(len(b), l.MaxBytesLength)
+	if n == len(b) {
+		return "", false
+	}
+
+	return base64.StdEncoding.EncodeToString(b[:n]) + truncatedSuffix(len(b)-n), true
+}
+
+// limited returns the number of items to keep out of n items according to the given maximum.
+// Zero or negative maximum means no limit.
+func limited(n, maximum int) int {
+	if maximum <= 0 || n <= maximum {
+		return n
+	}
+
+	return maximum
+}
+
+func truncatedSuffix(omitted int) string {
+	return truncatedSuffixPrefix + strconv.Itoa(omitted)
+}
+
+// ---
+
+const (
+	maxDepthMarker = "!MAXDEPTH"
+	maxNodesMarker = "!MAXNODES"
+	truncatedKey   = "!TRUNCATED"
+
+	truncatedSuffixPrefix = "...!TRUNCATED:"
+)
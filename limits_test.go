@@ -0,0 +1,199 @@
+package slogf_test
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestLimits(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag  LineTag
+		name     string
+		limits   slogf.Limits
+		attrs    []slog.Attr
+		expected string
+	}
+
+	tests := []test{
+		{
+			lineTag:  ThisLine(),
+			name:     "Cycle",
+			limits:   slogf.Limits{MaxDepth: 2},
+			attrs:    []slog.Attr{slog.Any("c", testCyclicValuer{"c"})},
+			expected: `{"level":"info","msg":"test","c":{"k":"c","c":{"k":"c","c":"!MAXDEPTH"}}}`,
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "CycleInline",
+			limits:   slogf.Limits{MaxDepth: 2},
+			attrs:    []slog.Attr{slog.Any("", testCyclicValuer{""})},
+			expected: `{"level":"info","msg":"test","k":"","k":""}`,
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "BranchingCycle",
+			limits:   slogf.Limits{MaxNodes: 5},
+			attrs:    []slog.Attr{slog.Any("b", testBranchingValuer{}), slog.Int("x", 1)},
+			expected: `{"level":"info","msg":"test","b":{"l":{"l":{"l":{"l":{"l":"!MAXNODES"}}}}}}`,
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "BranchingCycleSlice",
+			limits:   slogf.Limits{MaxNodes: 3},
+			attrs:    []slog.Attr{slog.Int("x", 1), slog.Any("s", testBranchingSlice())},
+			expected: `{"level":"info","msg":"test","x":1,"s":[[["!MAXNODES"]]]}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "CycleDefault",
+			attrs:   []slog.Attr{slog.Any("c", testCyclicValuer{"c"})},
+			expected: `{"level":"info","msg":"test","c":` +
+				strings.Repeat(`{"k":"c","c":`, slogf.DefaultMaxDepth) + `"!MAXDEPTH"` + strings.Repeat(`}`, slogf.DefaultMaxDepth+1),
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "Collections",
+			limits:   slogf.Limits{MaxDepth: 2},
+			attrs:    []slog.Attr{slog.Any("a", []any{1, []any{2, []any{3}}, map[string]any{"m": map[string]int{"x": 1}}})},
+			expected: `{"level":"info","msg":"test","a":[1,[2,"!MAXDEPTH"],{"m":"!MAXDEPTH"}]}`,
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "CollectionsAny",
+			limits:   slogf.Limits{MaxDepth: 2, MaxAttrs: 1},
+			attrs:    []slog.Attr{slog.Any("a", []any{nil, testCyclicValuer{"c"}, nil})},
+			expected: `{"level":"info","msg":"test","a":[null,{"k":"c","!TRUNCATED":1},null]}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "MaxAttrs",
+			limits:  slogf.Limits{MaxAttrs: 2},
+			attrs: []slog.Attr{
+				slog.Int("a", 1), slog.Int("b", 2), slog.Int("c", 3),
+				slog.Group("g", slog.Int("a", 1), slog.Int("b", 2), slog.Int("c", 3), slog.Int("d", 4)),
+			},
+			expected: `{"level":"info","msg":"test","a":1,"b":2,"c":3,"g":{"a":1,"b":2,"!TRUNCATED":2}}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "MaxStringLength",
+			limits:  slogf.Limits{MaxStringLength: 4},
+			attrs: []slog.Attr{
+				slog.String("a", "abcd"), slog.String("b", "abcdef"), slog.String("c", "abcд"),
+				slog.Any("s", testStringer{12345}), slog.Any("ss", []string{"abcdef"}),
+			},
+			expected: `{"level":"info","msg":"test","a":"abcd","b":"abcd...!TRUNCATED:2","c":"abc...!TRUNCATED:2",` +
+				`"s":"stri...!TRUNCATED:10","ss":["abcd...!TRUNCATED:2"]}`,
+		},
+		{
+			lineTag:  ThisLine(),
+			name:     "MaxBytesLength",
+			limits:   slogf.Limits{MaxBytesLength: 3},
+			attrs:    []slog.Attr{slog.Any("a", []byte("abc")), slog.Any("b", []byte("abcdef"))},
+			expected: `{"level":"info","msg":"test","a":"YWJj","b":"YWJj...!TRUNCATED:3"}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "MaxElements",
+			limits:  slogf.Limits{MaxElements: 2},
+			attrs: []slog.Attr{
+				slog.Any("a", []any{1, "x", true}),
+				slog.Any("s", []string{"a", "b", "c", "d"}),
+				slog.Any("i", []int{1, 2}),
+				slog.Any("ii", []int{1, 2, 3}),
+				slog.Any("m", map[string]int{"c": 3, "b": 2, "a": 1}),
+				slog.Any("f", []any{nil, 1, 2}),
+			},
+			expected: `{"level":"info","msg":"test","a":[1,"x","...!TRUNCATED:1"],"s":["a","b","...!TRUNCATED:2"],"i":[1,2],` +
+				`"ii":[1,2,"...!TRUNCATED:1"],"m":{"a":1,"b":2,"!TRUNCATED":1},"f":[null,1,"...!TRUNCATED:1"]}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "MaxElementsTyped",
+			limits:  slogf.Limits{MaxElements: 2},
+			attrs: []slog.Attr{
+				slog.Any("b", []bool{true, false, true}),
+				slog.Any("i64", []int64{1, 2, 3, 4}), slog.Any("i32", []int32{1, 2, 3}),
+				slog.Any("i16", []int16{1, 2, 3}), slog.Any("i8", []int8{1, 2, 3}),
+				slog.Any("u", []uint{1, 2, 3}), slog.Any("u64", []uint64{1, 2, 3}),
+				slog.Any("u32", []uint32{1, 2, 3}), slog.Any("u16", []uint16{1, 2, 3}),
+				slog.Any("f64", []float64{1, 2, 3, 4}), slog.Any("f32", []float32{1.5, 2, 3}),
+				slog.Any("d", []time.Duration{1, 2, 3}), slog.Any("short", []float64{1, 2}),
+			},
+			expected: `{"level":"info","msg":"test","b":[true,false,"...!TRUNCATED:1"],` +
+				`"i64":[1,2,"...!TRUNCATED:2"],"i32":[1,2,"...!TRUNCATED:1"],` +
+				`"i16":[1,2,"...!TRUNCATED:1"],"i8":[1,2,"...!TRUNCATED:1"],` +
+				`"u":[1,2,"...!TRUNCATED:1"],"u64":[1,2,"...!TRUNCATED:1"],` +
+				`"u32":[1,2,"...!TRUNCATED:1"],"u16":[1,2,"...!TRUNCATED:1"],` +
+				`"f64":[1,2,"...!TRUNCATED:2"],"f32":[1.5,2,"...!TRUNCATED:1"],` +
+				`"d":[1,2,"...!TRUNCATED:1"],"short":[1,2]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+			t.Expect(
+				testLog(testLogf(func(logfLogger *logf.Logger) {
+					handler := slogf.NewHandler().WithLogger(logfLogger).WithLimits(test.limits)
+					slog.New(handler).LogAttrs(context.Background(), slog.LevelInfo, "test", test.attrs...)
+				})),
+			).To(Equal([]string{test.expected}))
+		})
+	}
+
+	t.Run("BranchingCycleDefault", func(t Test) {
+		output := testLog(testLogf(func(logfLogger *logf.Logger) {
+			logger := slog.New(slogf.NewHandler().WithLogger(logfLogger)).With("w", testBranchingValuer{})
+			logger.Info("test", "b", testBranchingValuer{}, "s", testBranchingSlice())
+		}))
+		t.Expect(output).To(HaveLen(1))
+		t.Expect(strings.Count(output[0], `"l"`) + strings.Count(output[0], "[")).To(BeLessThan(3 * slogf.DefaultMaxNodes))
+		t.Expect(strings.Count(output[0], "!MAXNODES")).To(Equal(2))
+	})
+}
+
+// ---
+
+type testCyclicValuer struct {
+	key string
+}
+
+func (v testCyclicValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("k", v.key), slog.Any(v.key, v))
+}
+
+// ---
+
+// testBranchingValuer references itself twice, so the number of values it expands to grows exponentially with depth.
+type testBranchingValuer struct{}
+
+func (v testBranchingValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Any("l", v), slog.Any("r", v))
+}
+
+// ---
+
+func testBranchingSlice() []any {
+	s := make([]any, 2)
+	s[0], s[1] = s, s
+
+	return s
+}
+
+// ---
+
+var (
+	_ slog.LogValuer = testCyclicValuer{}
+	_ slog.LogValuer = testBranchingValuer{}
+)
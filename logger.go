@@ -0,0 +1,126 @@
+package slogf
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"unsafe"
+
+	"github.com/ssgreg/logf"
+)
+
+// errUnsupportedLogfVersion is returned by checkLoggerLayout if the layout of logf.Logger is not the expected one.
+var errUnsupportedLogfVersion = errors.New("slogf: unsupported logf version")
+
+// loggerLayoutError holds the result of checkLoggerLayout.
+// If it is not nil, loggers are used through the public API of logf.Logger.
+//
+//nolint:gochecknoglobals // the layout of logf.Logger is the same for the whole run of the program
+var loggerLayoutError = checkLoggerLayout()
+
+// loggerView mirrors the memory layout of logf.Logger.
+// It provides access to the logger internals that are not exposed by its public API
+// but are required to write entries with the time and caller information taken from slog records.
+// It is used only if the layout is verified by checkLoggerLayout.
+type loggerView struct {
+	level      logf.LevelChecker
+	id         int32
+	w          logf.EntryWriter
+	fields     []logf.Field
+	name       string
+	addCaller  bool
+	callerSkip int
+}
+
+// checkLoggerLayout returns an error if the fields of loggerView do not match the fields of logf.Logger
+// by name, type and offset, which may happen if a newer version of logf changes them.
+func checkLoggerLayout() error {
+	expected := reflect.TypeOf(logf.Logger{})
+	actual := reflect.TypeOf(loggerView{})
+
+	if expected.Size() != actual.Size() {
+		return fmt.Errorf("%w: logf.Logger has size %d, expected %d", errUnsupportedLogfVersion, expected.Size(), actual.Size())
+	}
+
+	if expected.NumField() != actual.NumField() {
+		return fmt.Errorf("%w: logf.Logger has %d fields, expected %d", errUnsupportedLogfVersion, expected.NumField(), actual.NumField())
+	}
+
+	for i := range expected.NumField() {
+		e, a := expected.Field(i), actual.Field(i)
+		if e.Name != a.Name || e.Type != a.Type || e.Offset != a.Offset {
+			return fmt.Errorf(
+				"%w: logf.Logger field %d is %s %v at offset %d, expected %s %v at offset %d",
+				errUnsupportedLogfVersion, i, e.Name, e.Type, e.Offset, a.Name, a.Type, a.Offset,
+			)
+		}
+	}
+
+	return nil
+}
+
+// loggerEnabled reports whether the logger writes entries of the given level.
+func loggerEnabled(logger *logf.Logger, level logf.Level) bool {
+	if loggerLayoutError != nil {
+		enabled := false
+		logger.AtLevel(level, func(logf.LogFunc) {
+			enabled = true
+		})
+
+		return enabled
+	}
+
+	return viewLogger(logger).level(level)
+}
+
+// writeEntry writes an entry for the record with the given level and fields.
+// If the layout of logf.Logger is not supported, the entry is written using the public API of logf.Logger,
+// so it gets the current time instead of the record time and the caller of this function instead of the record PC.
+func writeEntry(logger *logf.Logger, level logf.Level, record *slog.Record, fields []logf.Field) {
+	if loggerLayoutError != nil {
+		logger.AtLevel(level, func(log logf.LogFunc) {
+			log(record.Message, fields...)
+		})
+
+		return
+	}
+
+	viewLogger(logger).write(level, record, fields)
+}
+
+func viewLogger(logger *logf.Logger) *loggerView {
+	return (*loggerView)(unsafe.Pointer(logger))
+}
+
+func (l *loggerView) write(level logf.Level, record *slog.Record, fields []logf.Field) {
+	entry := logf.Entry{
+		LoggerID:      l.id,
+		LoggerName:    l.name,
+		DerivedFields: l.fields,
+		Fields:        fields,
+		Level:         level,
+		Time:          record.Time,
+		Text:          record.Message,
+	}
+
+	if l.addCaller && record.PC != 0 {
+		entry.Caller = entryCaller(record.PC)
+	}
+
+	l.w.WriteEntry(entry)
+}
+
+// ---
+
+func entryCaller(pc uintptr) logf.EntryCaller {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	return logf.EntryCaller{
+		PC:        frame.PC,
+		File:      frame.File,
+		Line:      frame.Line,
+		Specified: frame.File != "",
+	}
+}
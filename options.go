@@ -0,0 +1,33 @@
+package slogf
+
+import (
+	"log/slog"
+)
+
+// HandlerOptions are options for a Handler.
+// A zero HandlerOptions consists entirely of default values.
+type HandlerOptions struct {
+	// Level reports the minimum record level that will be logged.
+	// It is checked in addition to the level of the logf.Logger,
+	// so a record is logged only if both of them enable its level.
+	// If Level is nil, only the level of the logf.Logger is checked.
+	// Use a *slog.LevelVar to change the level dynamically.
+	Level slog.Leveler
+
+	// ReplaceAttr is called to rewrite each non-group attribute before it is logged.
+	// The attribute's value has been resolved (see slog.Value.Resolve).
+	// If ReplaceAttr returns a zero slog.Attr, the attribute is discarded.
+	//
+	// The first argument is a list of currently open groups that contain the attribute.
+	// It must not be retained or modified.
+	// ReplaceAttr is never called for group attributes, only for their contents.
+	//
+	// Attributes passed to WithAttrs are replaced only once, when the new handler is created,
+	// except those containing slog.LogValuer values at any depth, which are replaced when they are resolved,
+	// on the first record passing the level check that is handled by the new handler (see Handler).
+	// Attributes of a record are replaced each time the record is handled.
+	// The time and level attributes enabled by Handler.WithTimeKey and Handler.WithLevelKey
+	// are passed to ReplaceAttr with no groups.
+	// Other built-in attributes, like level and message, are encoded by logf and are not passed to ReplaceAttr.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
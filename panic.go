@@ -0,0 +1,157 @@
+package slogf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ssgreg/logf"
+)
+
+// panicMessage returns a placeholder for a value which conversion or encoding panicked with r.
+// Like slog handlers, it returns "<nil>" for nil pointers, which methods often panic on.
+func panicMessage(r any, value any) string {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("!PANIC: %v", r)
+}
+
+// protectField wraps values of the field that are encoded by the logf encoder
+// and may call user code, so that panics in that code are recovered.
+// Object and array fields holding no encoder, like the ones returned by custom converters, are left as is.
+func protectField(field *logf.Field) {
+	switch field.Type {
+	case logf.FieldTypeAny:
+		if field.Any != nil {
+			field.Any = safeMarshaler{field.Any}
+		}
+	case logf.FieldTypeObject:
+		if object, ok := field.Any.(logf.ObjectEncoder); ok {
+			field.Any = safeObject{object}
+		}
+	case logf.FieldTypeArray:
+		if array, ok := field.Any.(logf.ArrayEncoder); ok {
+			field.Any = safeArray{array}
+		}
+	}
+}
+
+// ---
+
+// safeMarshaler is a json.Marshaler which recovers from panics while marshaling the value
+// and represents marshaling errors the same way as slog.JSONHandler does.
+type safeMarshaler struct {
+	value any
+}
+
+func (m safeMarshaler) MarshalJSON() (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = json.Marshal(panicMessage(r, m.value))
+		}
+	}()
+
+	data, err = json.Marshal(m.value)
+	if err != nil {
+		return json.Marshal(fmt.Sprintf("!ERROR:%v", err))
+	}
+
+	return data, nil
+}
+
+// ---
+
+// safeObject is a logf.ObjectEncoder which recovers from panics in the wrapped encoder
+// adding a field with "!PANIC" key.
+type safeObject struct {
+	logf.ObjectEncoder
+}
+
+func (o safeObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	defer func() {
+		if r := recover(); r != nil {
+			enc.EncodeFieldString(panicKey, panicMessage(r, o.ObjectEncoder))
+		}
+	}()
+
+	return o.ObjectEncoder.EncodeLogfObject(enc)
+}
+
+// ---
+
+// safeArray is a logf.ArrayEncoder which recovers from panics in the wrapped encoder
+// adding an element with the placeholder.
+type safeArray struct {
+	logf.ArrayEncoder
+}
+
+func (a safeArray) EncodeLogfArray(enc logf.TypeEncoder) error {
+	defer func() {
+		if r := recover(); r != nil {
+			enc.EncodeTypeString(panicMessage(r, a.ArrayEncoder))
+		}
+	}()
+
+	return a.ArrayEncoder.EncodeLogfArray(enc)
+}
+
+// ---
+
+// safeError returns an error which recovers from panics in Error and Format methods of the given error,
+// because logf encoders call them when the entry is encoded.
+func safeError(err error) error {
+	//nolint:errorlint // only the error itself is formatted by logf
+	if _, ok := err.(fmt.Formatter); ok {
+		return safeFormatterError{safeErrorValue{err}}
+	}
+
+	return safeErrorValue{err}
+}
+
+// ---
+
+type safeErrorValue struct {
+	err error
+}
+
+func (e safeErrorValue) Error() (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = panicMessage(r, e.err)
+		}
+	}()
+
+	return e.err.Error()
+}
+
+// ---
+
+type safeFormatterError struct {
+	safeErrorValue
+}
+
+func (e safeFormatterError) Format(s fmt.State, verb rune) {
+	defer func() {
+		if r := recover(); r != nil {
+			_, _ = fmt.Fprint(s, panicMessage(r, e.err))
+		}
+	}()
+
+	e.err.(fmt.Formatter).Format(s, verb) //nolint:errorlint,forcetypeassert // checked by safeError
+}
+
+// ---
+
+const panicKey = "!PANIC"
+
+// ---
+
+var (
+	_ json.Marshaler     = safeMarshaler{}
+	_ logf.ObjectEncoder = safeObject{}
+	_ logf.ArrayEncoder  = safeArray{}
+	_ error              = safeErrorValue{}
+	_ fmt.Formatter      = safeFormatterError{}
+)
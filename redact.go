@@ -0,0 +1,278 @@
+package slogf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultRedactionMask is the mask used for redacted values if Redaction.Mask is empty.
+const DefaultRedactionMask = "[REDACTED]"
+
+// NewSecret returns a Secret wrapping the given value.
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value}
+}
+
+// ---
+
+// Secret wraps a sensitive value, so that it is redacted by Handler regardless of the redaction rules.
+// If no redaction is configured, it is replaced with DefaultRedactionMask.
+// It also implements slog.LogValuer, fmt.Stringer, fmt.Formatter and json.Marshaler returning the mask,
+// so that it is not disclosed by other handlers and formatters either.
+type Secret[T any] struct {
+	value T
+}
+
+// Reveal returns the wrapped value.
+func (s Secret[T]) Reveal() T {
+	return s.value
+}
+
+// LogValue returns DefaultRedactionMask.
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(DefaultRedactionMask)
+}
+
+// String returns DefaultRedactionMask.
+func (s Secret[T]) String() string {
+	return DefaultRedactionMask
+}
+
+// Format writes DefaultRedactionMask regardless of the verb and flags.
+func (s Secret[T]) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, DefaultRedactionMask)
+}
+
+// MarshalJSON returns DefaultRedactionMask as a JSON string.
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(DefaultRedactionMask)), nil
+}
+
+func (s Secret[T]) secretValue() any {
+	return s.value
+}
+
+// ---
+
+// RedactionMode specifies how redacted values are replaced.
+type RedactionMode int
+
+// Redaction modes.
+const (
+	// RedactMask replaces values with the mask.
+	RedactMask RedactionMode = iota
+	// RedactHash replaces values with "sha256:" prefix followed by the hex-encoded first 16 bytes
+	// of SHA-256 or HMAC-SHA256 hash of their string representation,
+	// so that equal values can be correlated without being disclosed.
+	RedactHash
+)
+
+// Redaction specifies which attribute values are redacted and how.
+// Values are redacted before they are passed to ReplaceAttr or converted to logf fields,
+// including attributes added by WithAttrs, record attributes, attributes of groups and entries of maps,
+// regardless of the other elements of slices containing them.
+// ReplaceAttr receives redacted attributes with their original keys and the replacement string values.
+// Secret values are always redacted.
+//
+// Patterns use the syntax of path.Match and are matched case-insensitively.
+type Redaction struct {
+	// Keys are patterns matched against attribute keys, like "password" or "*token*".
+	Keys []string
+
+	// Paths are dot-separated patterns matched segment by segment against the full attribute path,
+	// which consists of the names of the groups containing the attribute and the attribute key,
+	// like "request.headers.authorization" or "*.credentials".
+	// Groups opened by WithGroup are included in the path.
+	// Elements of slices have the same path as the slice, so "items.id" matches the "id" entries of the groups and maps in "items".
+	Paths []string
+
+	// Mode specifies how redacted values are replaced.
+	Mode RedactionMode
+
+	// Mask replaces redacted values in RedactMask mode.
+	// If Mask is empty, DefaultRedactionMask is used.
+	Mask string
+
+	// HashKey is a key for HMAC-SHA256 used in RedactHash mode.
+	// It is strongly recommended to set it for low-entropy values like card numbers,
+	// otherwise plain SHA-256 is used, which can be reversed by brute force.
+	HashKey []byte
+}
+
+func (r Redaction) redactor() *redactor {
+	result := &redactor{
+		keys:    make(map[string]struct{}),
+		mode:    r.Mode,
+		mask:    r.Mask,
+		hashKey: r.HashKey,
+	}
+
+	if result.mask == "" {
+		result.mask = DefaultRedactionMask
+	}
+
+	for _, pattern := range r.Keys {
+		pattern = strings.ToLower(pattern)
+		mustValidatePattern(pattern)
+
+		if strings.ContainsAny(pattern, `*?[\`) {
+			result.keyPatterns = append(result.keyPatterns, pattern)
+		} else {
+			result.keys[pattern] = struct{}{}
+		}
+	}
+
+	for _, pattern := range r.Paths {
+		segments := strings.Split(strings.ToLower(pattern), ".")
+		for _, segment := range segments {
+			mustValidatePattern(segment)
+		}
+
+		result.paths = append(result.paths, segments)
+	}
+
+	return result
+}
+
+func mustValidatePattern(pattern string) {
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		panic(fmt.Sprintf("slogf: invalid redaction pattern %q: %v", pattern, err))
+	}
+}
+
+// ---
+
+type redactor struct {
+	keys        map[string]struct{}
+	keyPatterns []string
+	paths       [][]string
+	mode        RedactionMode
+	mask        string
+	hashKey     []byte
+}
+
+func (r *redactor) usesPaths() bool {
+	return r != nil && len(r.paths) != 0
+}
+
+// redact returns the redacted value of the attribute and true if the attribute has to be redacted.
+func (r *redactor) redact(groups []string, attr slog.Attr) (string, bool) {
+	if secret, ok := secretOf(attr.Value); ok {
+		return r.redactSecret(secret), true
+	}
+
+	if r == nil || !r.matches(groups, attr.Key) {
+		return "", false
+	}
+
+	return r.replacement(attr.Value.Resolve().String()), true
+}
+
+func (r *redactor) redactSecret(secret any) string {
+	if r == nil {
+		return DefaultRedactionMask
+	}
+
+	switch v := secret.(type) {
+	case string:
+		return r.replacement(v)
+	case []byte:
+		return r.replacement(string(v))
+	default:
+		return r.replacement(fmt.Sprint(v))
+	}
+}
+
+func (r *redactor) replacement(value string) string {
+	if r.mode != RedactHash {
+		return r.mask
+	}
+
+	var sum []byte
+
+	if len(r.hashKey) != 0 {
+		mac := hmac.New(sha256.New, r.hashKey)
+		_, _ = mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		hash := sha256.Sum256([]byte(value))
+		sum = hash[:]
+	}
+
+	return "sha256:" + hex.EncodeToString(sum[:16])
+}
+
+func (r *redactor) matches(groups []string, key string) bool {
+	key = strings.ToLower(key)
+
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+
+	for _, pattern := range r.keyPatterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+
+	for _, pattern := range r.paths {
+		if r.matchesPath(pattern, groups, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *redactor) matchesPath(pattern, groups []string, key string) bool {
+	if len(pattern) != len(groups)+1 {
+		return false
+	}
+
+	for i, group := range groups {
+		if matched, _ := path.Match(pattern[i], strings.ToLower(group)); !matched {
+			return false
+		}
+	}
+
+	matched, _ := path.Match(pattern[len(groups)], key)
+
+	return matched
+}
+
+// ---
+
+func secretOf(value slog.Value) (any, bool) {
+	if value.Kind() != slog.KindLogValuer {
+		return nil, false
+	}
+
+	secret, ok := value.LogValuer().(interface{ secretValue() any })
+	if !ok {
+		return nil, false
+	}
+
+	// A nil *Secret still has to be redacted, but calling its value method would panic.
+	if v := reflect.ValueOf(secret); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, true
+	}
+
+	return secret.secretValue(), true
+}
+
+// ---
+
+var (
+	_ slog.LogValuer = Secret[string]{}
+	_ fmt.Stringer   = Secret[string]{}
+	_ fmt.Formatter  = Secret[string]{}
+)
@@ -0,0 +1,193 @@
+package slogf_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestRedaction(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag   LineTag
+		name      string
+		redaction *slogf.Redaction
+		group     string
+		with      []slog.Attr
+		attrs     []slog.Attr
+		expected  string
+	}
+
+	tests := []test{
+		{
+			lineTag:   ThisLine(),
+			name:      "Keys",
+			redaction: &slogf.Redaction{Keys: []string{"password", "*token*"}},
+			attrs: []slog.Attr{
+				slog.String("Password", "p"), slog.String("accessToken", "t"), slog.String("user", "u"),
+				slog.Group("g", slog.Int("password", 1)),
+			},
+			expected: `{"level":"info","msg":"test","Password":"[REDACTED]","accessToken":"[REDACTED]","user":"u",` +
+				`"g":{"password":"[REDACTED]"}}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "KeysWithAttrs",
+			redaction: &slogf.Redaction{Keys: []string{"password"}, Mask: "***"},
+			group:     "g",
+			with:      []slog.Attr{slog.String("password", "p"), slog.Any("v", testGroupValuer{slog.String("password", "p")})},
+			attrs:     []slog.Attr{slog.String("password", "p")},
+			expected:  `{"level":"info","msg":"test","g":{"password":"***","v":{"password":"***"},"password":"***"}}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "Paths",
+			redaction: &slogf.Redaction{Paths: []string{"request.headers.authorization", "*.credentials"}},
+			group:     "request",
+			attrs: []slog.Attr{
+				slog.Group("headers", slog.String("Authorization", "a"), slog.String("accept", "b")),
+				slog.String("authorization", "c"),
+				slog.Any("credentials", map[string]string{"user": "u"}),
+			},
+			expected: `{"level":"info","msg":"test","request":{"headers":{"Authorization":"[REDACTED]","accept":"b"},` +
+				`"authorization":"c","credentials":"[REDACTED]"}}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "PathsCollections",
+			redaction: &slogf.Redaction{Paths: []string{"m.password", "a.password"}},
+			attrs: []slog.Attr{
+				slog.Any("m", map[string]any{"password": "p", "user": "u"}),
+				slog.Any("a", []any{nil, slog.GroupValue(slog.String("password", "p"))}),
+			},
+			expected: `{"level":"info","msg":"test","m":{"password":"[REDACTED]","user":"u"},"a":[null,{"password":"[REDACTED]"}]}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "CollectionsMixed",
+			redaction: &slogf.Redaction{Keys: []string{"password"}, Paths: []string{"y.token"}},
+			attrs: []slog.Attr{
+				slog.Any("x", []any{map[string]string{"password": "p1"}, struct{ A int }{1}, map[string]any{"password": "p2"}}),
+				slog.Any("y", []any{nil, map[string]string{"token": "t", "user": "u"}}),
+			},
+			expected: `{"level":"info","msg":"test","x":[{"password":"[REDACTED]"},{"A":1},{"password":"[REDACTED]"}],` +
+				`"y":[null,{"token":"[REDACTED]","user":"u"}]}`,
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Secret",
+			attrs: []slog.Attr{
+				slog.Any("s", slogf.NewSecret("p")),
+				slog.Any("a", []any{1, slogf.NewSecret(2)}),
+				slog.Any("m", map[string]any{"k": slogf.NewSecret("v")}),
+			},
+			expected: `{"level":"info","msg":"test","s":"[REDACTED]","a":[1,"[REDACTED]"],"m":{"k":"[REDACTED]"}}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "Hash",
+			redaction: &slogf.Redaction{Keys: []string{"card"}, Mode: slogf.RedactHash},
+			attrs:     []slog.Attr{slog.String("card", "4111"), slog.Any("s", slogf.NewSecret("x"))},
+			expected: `{"level":"info","msg":"test","card":"sha256:1f58dbec71994620de8abe61e744f76d",` +
+				`"s":"sha256:2d711642b726b04401627ca9fbac32f5"}`,
+		},
+		{
+			lineTag:   ThisLine(),
+			name:      "HashKey",
+			redaction: &slogf.Redaction{Keys: []string{"card"}, Mode: slogf.RedactHash, HashKey: []byte("key")},
+			attrs:     []slog.Attr{slog.Int("card", 4111)},
+			expected:  `{"level":"info","msg":"test","card":"sha256:af7765bc688fb399e2b836cf292efba8"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+			t.Expect(
+				testLog(testLogf(func(logfLogger *logf.Logger) {
+					handler := slogf.NewHandler().WithLogger(logfLogger)
+					if test.redaction != nil {
+						handler = handler.WithRedaction(*test.redaction)
+					}
+
+					logger := slog.New(handler)
+					if test.group != "" {
+						logger = logger.WithGroup(test.group)
+					}
+
+					logger.With(attrsToAny(test.with)...).LogAttrs(context.Background(), slog.LevelInfo, "test", test.attrs...)
+				})),
+			).To(Equal([]string{test.expected}))
+		})
+	}
+
+	t.Run("ReplaceAttr", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandlerWithOptions(&slogf.HandlerOptions{
+					ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+						a.Key = "X_" + a.Key
+
+						return a
+					},
+				}).WithLogger(logfLogger).WithRedaction(slogf.Redaction{Keys: []string{"password"}})
+				slog.New(handler).Info("test", "password", "p", "user", "u", "s", slogf.NewSecret("x"))
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","X_password":"[REDACTED]","X_user":"u","X_s":"[REDACTED]"}`}))
+	})
+
+	t.Run("NilSecret", func(t Test) {
+		t.Expect(
+			testLog(testLogf(func(logfLogger *logf.Logger) {
+				handler := slogf.NewHandler().WithLogger(logfLogger)
+				slog.New(handler).Info("test", "s", (*slogf.Secret[string])(nil), "a", []any{(*slogf.Secret[int])(nil)})
+			})),
+		).To(Equal([]string{`{"level":"info","msg":"test","s":"[REDACTED]","a":["[REDACTED]"]}`}))
+	})
+
+	t.Run("SecretElsewhere", func(t Test) {
+		secret := slogf.NewSecret("p")
+		t.Expect(secret.Reveal()).To(Equal("p"))
+		t.Expect(fmt.Sprintf("%v %+v %#v %s %q", secret, secret, secret, secret, secret)).To(
+			Equal("[REDACTED] [REDACTED] [REDACTED] [REDACTED] [REDACTED]"),
+		)
+
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Info("test", "s", secret)
+		t.Expect(strings.Contains(buf.String(), `"s":"[REDACTED]"`)).To(BeTrue())
+	})
+
+	t.Run("InvalidPattern", func(t Test) {
+		panicked := func() (panicked bool) {
+			defer func() {
+				panicked = recover() != nil
+			}()
+
+			slogf.NewHandler().WithRedaction(slogf.Redaction{Keys: []string{"["}})
+
+			return false
+		}
+
+		t.Expect(panicked()).To(BeTrue())
+	})
+}
+
+// ---
+
+func attrsToAny(attrs []slog.Attr) []any {
+	result := make([]any, len(attrs))
+	for i, attr := range attrs {
+		result[i] = attr
+	}
+
+	return result
+}
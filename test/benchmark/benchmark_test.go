@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"strconv"
 	"testing"
 
 	"github.com/ssgreg/logf"
@@ -27,6 +28,80 @@ func BenchmarkAtLevel(b *testing.B) {
 	})
 }
 
+func BenchmarkEnabled(b *testing.B) {
+	benchSlogf(b, func(ctx context.Context, b *testing.B, logger *slog.Logger) {
+		b.Helper()
+		b.ReportAllocs()
+		b.ResetTimer()
+		counter := 0
+		for i := 0; i < b.N; i++ {
+			if logger.Enabled(ctx, slog.LevelInfo) {
+				counter++
+			}
+		}
+		b.StopTimer()
+		logger.Info("test", slog.Int("counter", counter))
+	})
+}
+
+func TestEnabledAllocs(t *testing.T) {
+	for _, level := range []logf.Level{logf.LevelDebug, logf.LevelWarn} {
+		logger := slog.New(slogf.NewHandler())
+		ctx := logf.NewContext(context.Background(), logf.NewLogger(level, logf.NewUnbufferedEntryWriter(logf.NewDiscardAppender())))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			logger.Enabled(ctx, slog.LevelInfo)
+		})
+		if allocs != 0 {
+			t.Errorf("level %v: got %v allocs per Enabled call, want 0", level, allocs)
+		}
+	}
+
+	t.Run("Drop", func(t *testing.T) {
+		logger := slog.New(slogf.NewHandler())
+		ctx := logf.NewContext(context.Background(), logf.NewLogger(logf.LevelWarn, logf.NewUnbufferedEntryWriter(logf.NewDiscardAppender())))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.String("key", "value"))
+		})
+		if allocs != 0 {
+			t.Errorf("got %v allocs per dropped record, want 0", allocs)
+		}
+	})
+}
+
+func TestHandleAllocs(t *testing.T) {
+	appender := logf.NewWriteAppender(io.Discard, logf.NewJSONEncoder(logf.JSONEncoderConfig{}))
+	ctx := logf.NewContext(context.Background(), logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender)))
+	logger := slog.New(slogf.NewHandler().WithBufferReuse(true)).With("a", 1).WithGroup("g").With("b", 2)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.String("key", "value"), slog.Int("n", 42))
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocs per record, want 0", allocs)
+	}
+}
+
+func TestHandleAllocsAlternatingContexts(t *testing.T) {
+	newContext := func() context.Context {
+		appender := logf.NewWriteAppender(io.Discard, logf.NewJSONEncoder(logf.JSONEncoderConfig{}))
+
+		return logf.NewContext(context.Background(), logf.NewLogger(logf.LevelDebug, logf.NewUnbufferedEntryWriter(appender)))
+	}
+
+	ctx1, ctx2 := newContext(), newContext()
+	logger := slog.New(slogf.NewHandler().WithBufferReuse(true)).With("svc", "x")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.LogAttrs(ctx1, slog.LevelInfo, "test", slog.Int("n", 1))
+		logger.LogAttrs(ctx2, slog.LevelInfo, "test", slog.Int("n", 2))
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocs per two records, want 0", allocs)
+	}
+}
+
 func BenchmarkLogging(b *testing.B) {
 	b.Run("Simple", func(b *testing.B) {
 		b.Run("slog", func(b *testing.B) {
@@ -291,6 +366,21 @@ func BenchmarkLogging(b *testing.B) {
 				b.StopTimer()
 			})
 		})
+		b.Run("slogf+WithLogger", func(b *testing.B) {
+			benchSlogfWithLogger(b, func(ctx context.Context, b *testing.B, logger *slog.Logger) {
+				b.Helper()
+				logger = logger.With(
+					slog.String("a", "a1"),
+					slog.Int("b", 42),
+					slog.String("x", "x1"),
+				)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					logger.LogAttrs(ctx, slog.LevelInfo, "test")
+				}
+				b.StopTimer()
+			})
+		})
 		b.Run("slogf+x", func(b *testing.B) {
 			benchSlogfX(b, func(ctx context.Context, b *testing.B, logger *slogx.Logger) {
 				b.Helper()
@@ -352,6 +442,21 @@ func BenchmarkLogging(b *testing.B) {
 				}
 			})
 		})
+		b.Run("slogf+WithLogger", func(b *testing.B) {
+			benchSlogfWithLogger(b, func(ctx context.Context, b *testing.B, logger *slog.Logger) {
+				b.Helper()
+				logger = logger.With(
+					slog.String("a", "a1"),
+					slog.Int("b", 42),
+					slog.String("x", "x1"),
+				)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.String("c", "d"), slog.Int("e", 10), slog.String("f", "g"))
+				}
+				b.StopTimer()
+			})
+		})
 		b.Run("slogf+x", func(b *testing.B) {
 			benchSlogfX(b, func(ctx context.Context, b *testing.B, logger *slogx.Logger) {
 				b.Helper()
@@ -384,17 +489,85 @@ func BenchmarkLogging(b *testing.B) {
 	})
 }
 
+func BenchmarkLogAfterWithGroups(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(strconv.Itoa(depth), func(b *testing.B) {
+			benchSlogfLevel(b, logf.LevelDebug, false, func(ctx context.Context, b *testing.B, logger *slog.Logger) {
+				b.Helper()
+				for i := 0; i < depth; i++ {
+					logger = logger.WithGroup("g").With(slog.Int("a", i))
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.String("key", "value"))
+				}
+				b.StopTimer()
+			})
+		})
+	}
+}
+
+func BenchmarkDeepContext(b *testing.B) {
+	type key struct{ int }
+
+	deepen := func(ctx context.Context) context.Context {
+		for i := 0; i < 20; i++ {
+			ctx = context.WithValue(ctx, key{i}, i)
+		}
+
+		return ctx
+	}
+
+	b.Run("slogf", func(b *testing.B) {
+		benchSlogfLevel(b, logf.LevelDebug, false, func(ctx context.Context, b *testing.B, logger *slog.Logger) {
+			b.Helper()
+			ctx = deepen(ctx)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.LogAttrs(ctx, slog.LevelInfo, "test", slog.String("key", "value"))
+			}
+			b.StopTimer()
+		})
+	})
+	b.Run("slogf+ctx", func(b *testing.B) {
+		benchSlogfLevel(b, logf.LevelDebug, false, func(ctx context.Context, b *testing.B, _ *slog.Logger) {
+			b.Helper()
+			ctx = slogf.ContextWithHandler(deepen(ctx), slogf.NewHandler())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				slogf.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelInfo, "test", slog.String("key", "value"))
+			}
+			b.StopTimer()
+		})
+	})
+}
+
 func benchSlogf(b *testing.B, f func(context.Context, *testing.B, *slog.Logger)) {
 	b.Helper()
+	benchSlogfWith(b, false, f)
+}
+
+// benchSlogfWithLogger runs the benchmark with the logf logger set by Handler.WithLogger
+// instead of the one taken from the context.
+func benchSlogfWithLogger(b *testing.B, f func(context.Context, *testing.B, *slog.Logger)) {
+	b.Helper()
+	benchSlogfWith(b, true, f)
+}
+
+func benchSlogfWith(b *testing.B, withLogger bool, f func(context.Context, *testing.B, *slog.Logger)) {
+	b.Helper()
 
 	test := func(b *testing.B, withCaller bool, f func(context.Context, *testing.B, *slog.Logger)) {
 		b.Helper()
 
 		b.Run("Pass", func(b *testing.B) {
-			benchSlogfLevel(b, logf.LevelDebug, withCaller, f)
+			benchSlogfHandler(b, logf.LevelDebug, withCaller, withLogger, f)
 		})
 		b.Run("Drop", func(b *testing.B) {
-			benchSlogfLevel(b, logf.LevelWarn, withCaller, f)
+			benchSlogfHandler(b, logf.LevelWarn, withCaller, withLogger, f)
 		})
 	}
 
@@ -441,6 +614,11 @@ func benchSlogfX(b *testing.B, f func(context.Context, *testing.B, *slogx.Logger
 }
 
 func benchSlogfLevel(b *testing.B, level logf.Level, withCaller bool, f func(context.Context, *testing.B, *slog.Logger)) {
+	b.Helper()
+	benchSlogfHandler(b, level, withCaller, false, f)
+}
+
+func benchSlogfHandler(b *testing.B, level logf.Level, withCaller, withLogger bool, f func(context.Context, *testing.B, *slog.Logger)) {
 	b.Helper()
 	handler := slogf.NewHandler()
 	appender := logf.NewWriteAppender(io.Discard, logf.NewJSONEncoder(logf.JSONEncoderConfig{
@@ -458,6 +636,10 @@ func benchSlogfLevel(b *testing.B, level logf.Level, withCaller bool, f func(con
 		logfLogger = logfLogger.WithCaller()
 	}
 
+	if withLogger {
+		handler = handler.WithLogger(logfLogger)
+	}
+
 	ctx := logf.NewContext(context.Background(), logfLogger)
 	logger := slog.New(handler)
 	f(ctx, b, logger)
@@ -0,0 +1,80 @@
+package slogf
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/ssgreg/logf"
+)
+
+// ConvertValue returns a ValueConverter which converts values of type T to logf fields using the given function.
+// Values are matched by their dynamic type exactly, so T must be a concrete type.
+// It panics if T is an interface type.
+func ConvertValue[T any](convert func(key string, value T) logf.Field) ValueConverter {
+	return newValueConverter(func(key string, value T) logf.Field {
+		return convert(key, value)
+	})
+}
+
+// ConvertObject returns a ValueConverter which converts values of type T to logf objects using the given function.
+// Values are matched by their dynamic type exactly, so T must be a concrete type.
+// It panics if T is an interface type.
+func ConvertObject[T any](convert func(value T) logf.ObjectEncoder) ValueConverter {
+	return newValueConverter(func(key string, value T) logf.Field {
+		return logf.Object(key, convert(value))
+	})
+}
+
+func newValueConverter[T any](convert func(key string, value T) logf.Field) ValueConverter {
+	valueType := reflect.TypeFor[T]()
+	if valueType.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("slogf: value converter for interface type %v never matches any value", valueType))
+	}
+
+	return ValueConverter{
+		valueType,
+		func(key string, value any) logf.Field {
+			v, ok := value.(T)
+			if !ok {
+				return logf.Any(key, value)
+			}
+
+			return convert(key, v)
+		},
+	}
+}
+
+// ---
+
+// ValueConverter converts values of a specific type to logf fields.
+// Use ConvertValue or ConvertObject to create one and Handler.WithValueConverters to register it.
+type ValueConverter struct {
+	valueType reflect.Type
+	convert   func(key string, value any) logf.Field
+}
+
+// ---
+
+type valueConverters map[reflect.Type]func(key string, value any) logf.Field
+
+func (c valueConverters) with(converters ...ValueConverter) valueConverters {
+	c = maps.Clone(c)
+	if c == nil {
+		c = make(valueConverters, len(converters))
+	}
+
+	for _, converter := range converters {
+		c[converter.valueType] = converter.convert
+	}
+
+	return c
+}
+
+func (c valueConverters) find(value any) func(key string, value any) logf.Field {
+	if len(c) == 0 {
+		return nil
+	}
+
+	return c[reflect.TypeOf(value)]
+}
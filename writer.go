@@ -0,0 +1,385 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"unsafe"
+
+	"github.com/ssgreg/logf"
+)
+
+// NewEntryWriter returns a new logf.EntryWriter which converts logf entries to slog records
+// and passes them to the given slog.Handler.
+func NewEntryWriter(handler slog.Handler) *EntryWriter {
+	return &EntryWriter{handler, DefaultLevelMapping()}
+}
+
+// ---
+
+// EntryWriter is a logf.EntryWriter implementation which uses slog.Handler to handle entries.
+//
+// Logger name is passed as an attribute with logf.DefaultFieldKeyName key.
+// Derived fields of the logger are passed before the fields of the entry.
+// Errors returned by the handler are ignored because logf.EntryWriter has no way to report them.
+type EntryWriter struct {
+	handler slog.Handler
+	levels  LevelMapping
+}
+
+// WithLevelMapping returns a new EntryWriter which uses the given mapping to convert logf levels to slog levels.
+func (w *EntryWriter) WithLevelMapping(mapping LevelMapping) *EntryWriter {
+	return &EntryWriter{w.handler, mapping}
+}
+
+// WriteEntry converts the given entry to slog.Record and passes it to the handler.
+func (w *EntryWriter) WriteEntry(entry logf.Entry) {
+	ctx := context.Background()
+	level := w.levels.SlogLevel(entry.Level)
+
+	if !w.handler.Enabled(ctx, level) {
+		return
+	}
+
+	var pc uintptr
+	if entry.Caller.Specified && entry.Caller.PC != 0 {
+		// EntryCaller.PC is a program counter of the call instruction as returned by runtime.Caller,
+		// but slog.Record.PC is expected to be a return address as returned by runtime.Callers.
+		pc = entry.Caller.PC + 1
+	}
+
+	enc := attrEncoder{make([]slog.Attr, 0, 1+len(entry.DerivedFields)+len(entry.Fields))}
+
+	if entry.LoggerName != "" {
+		enc.attrs = append(enc.attrs, slog.String(logf.DefaultFieldKeyName, entry.LoggerName))
+	}
+
+	for _, field := range entry.DerivedFields {
+		field.Accept(&enc)
+	}
+
+	for _, field := range entry.Fields {
+		field.Accept(&enc)
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Text, pc)
+	record.AddAttrs(enc.attrs...)
+
+	_ = w.handler.Handle(ctx, record)
+}
+
+// ---
+
+// attrEncoder is a logf.FieldEncoder which collects slog attributes.
+type attrEncoder struct {
+	attrs []slog.Attr
+}
+
+func (e *attrEncoder) EncodeFieldAny(k string, v any) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldBool(k string, v bool) {
+	e.add(slog.Bool(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInt64(k string, v int64) {
+	e.add(slog.Int64(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInt32(k string, v int32) {
+	e.add(slog.Int64(k, int64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldInt16(k string, v int16) {
+	e.add(slog.Int64(k, int64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldInt8(k string, v int8) {
+	e.add(slog.Int64(k, int64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldUint64(k string, v uint64) {
+	e.add(slog.Uint64(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldUint32(k string, v uint32) {
+	e.add(slog.Uint64(k, uint64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldUint16(k string, v uint16) {
+	e.add(slog.Uint64(k, uint64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldUint8(k string, v uint8) {
+	e.add(slog.Uint64(k, uint64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldFloat64(k string, v float64) {
+	e.add(slog.Float64(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldFloat32(k string, v float32) {
+	e.add(slog.Float64(k, float64(v)))
+}
+
+func (e *attrEncoder) EncodeFieldDuration(k string, v time.Duration) {
+	e.add(slog.Duration(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldError(k string, v error) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldTime(k string, v time.Time) {
+	e.add(slog.Time(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldString(k string, v string) {
+	e.add(slog.String(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldStrings(k string, v []string) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldBytes(k string, v []byte) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldBools(k string, v []bool) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInts64(k string, v []int64) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInts32(k string, v []int32) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInts16(k string, v []int16) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldInts8(k string, v []int8) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldUints64(k string, v []uint64) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldUints32(k string, v []uint32) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldUints16(k string, v []uint16) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldUints8(k string, v []uint8) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldFloats64(k string, v []float64) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldFloats32(k string, v []float32) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldDurations(k string, v []time.Duration) {
+	e.add(slog.Any(k, v))
+}
+
+func (e *attrEncoder) EncodeFieldArray(k string, v logf.ArrayEncoder) {
+	var enc anyEncoder
+	_ = v.EncodeLogfArray(&enc)
+
+	e.add(slog.Any(k, enc.values))
+}
+
+func (e *attrEncoder) EncodeFieldObject(k string, v logf.ObjectEncoder) {
+	var enc attrEncoder
+	_ = v.EncodeLogfObject(&enc)
+
+	e.add(slog.Attr{Key: k, Value: slog.GroupValue(enc.attrs...)})
+}
+
+func (e *attrEncoder) add(attr slog.Attr) {
+	e.attrs = append(e.attrs, attr)
+}
+
+// ---
+
+// anyEncoder is a logf.TypeEncoder which collects array elements as values of type any.
+// Nested objects are collected as maps, so they can be marshaled by slog handlers.
+type anyEncoder struct {
+	values []any
+}
+
+func (e *anyEncoder) EncodeTypeAny(v any) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeBool(v bool) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInt64(v int64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInt32(v int32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInt16(v int16) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInt8(v int8) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUint64(v uint64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUint32(v uint32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUint16(v uint16) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUint8(v uint8) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeFloat64(v float64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeFloat32(v float32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeDuration(v time.Duration) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeTime(v time.Time) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeString(v string) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeStrings(v []string) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeBytes(v []byte) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeBools(v []bool) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInts64(v []int64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInts32(v []int32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInts16(v []int16) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeInts8(v []int8) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUints64(v []uint64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUints32(v []uint32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUints16(v []uint16) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeUints8(v []uint8) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeFloats64(v []float64) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeFloats32(v []float32) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeDurations(v []time.Duration) {
+	e.add(v)
+}
+
+func (e *anyEncoder) EncodeTypeArray(v logf.ArrayEncoder) {
+	var enc anyEncoder
+	_ = v.EncodeLogfArray(&enc)
+
+	e.add(enc.values)
+}
+
+func (e *anyEncoder) EncodeTypeObject(v logf.ObjectEncoder) {
+	var enc attrEncoder
+	_ = v.EncodeLogfObject(&enc)
+
+	e.add(attrMap(enc.attrs))
+}
+
+func (e *anyEncoder) EncodeTypeUnsafeBytes(v unsafe.Pointer) {
+	e.add(string(*(*[]byte)(v)))
+}
+
+func (e *anyEncoder) add(v any) {
+	e.values = append(e.values, v)
+}
+
+// ---
+
+func attrMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			m[attr.Key] = attrMap(value.Group())
+		} else {
+			m[attr.Key] = value.Any()
+		}
+	}
+
+	return m
+}
+
+// ---
+
+var (
+	_ logf.EntryWriter  = (*EntryWriter)(nil)
+	_ logf.FieldEncoder = (*attrEncoder)(nil)
+	_ logf.TypeEncoder  = (*anyEncoder)(nil)
+)
@@ -0,0 +1,205 @@
+package slogf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	. "github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/slogf"
+)
+
+func TestEntryWriter(tt *testing.T) {
+	t := New(tt)
+
+	type test struct {
+		lineTag  LineTag
+		name     string
+		log      func(*logf.Logger)
+		expected []string
+	}
+
+	tests := []test{
+		{
+			lineTag: ThisLine(),
+			name:    "Simple",
+			log: func(logger *logf.Logger) {
+				logger.Info("test", logf.String("key", "value"))
+			},
+			expected: []string{`{"level":"INFO","msg":"test","key":"value"}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Levels",
+			log: func(logger *logf.Logger) {
+				logger.Debug("test 1")
+				logger.Info("test 2")
+				logger.Warn("test 3")
+				logger.Error("test 4")
+			},
+			expected: []string{
+				`{"level":"DEBUG","msg":"test 1"}`,
+				`{"level":"INFO","msg":"test 2"}`,
+				`{"level":"WARN","msg":"test 3"}`,
+				`{"level":"ERROR","msg":"test 4"}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "WithAndName",
+			log: func(logger *logf.Logger) {
+				logger.WithName("n1").WithName("n2").With(logf.Int("a", 1)).Info("test", logf.Bool("b", true))
+			},
+			expected: []string{`{"level":"INFO","msg":"test","logger":"n1.n2","a":1,"b":true}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Values",
+			log: func(logger *logf.Logger) {
+				logger.Info("test",
+					logf.Int32("i32", -32),
+					logf.Uint8("u8", 8),
+					logf.Float32("f32", 1.5),
+					logf.Duration("d", time.Second),
+					logf.Time("t", time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)),
+					logf.NamedError("err", errors.New("failure")),
+					logf.Strings("ss", []string{"a", "b"}),
+					logf.Ints("is", []int{1, 2}),
+					logf.Any("any", struct{ X int }{42}),
+				)
+			},
+			expected: []string{
+				`{"level":"INFO","msg":"test","i32":-32,"u8":8,"f32":1.5,"d":1000000000,"t":"2020-01-02T03:04:05.000000006Z",` +
+					`"err":"failure","ss":["a","b"],"is":[1,2],"any":{"X":42}}`,
+			},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Object",
+			log: func(logger *logf.Logger) {
+				logger.Info("test", logf.Object("o", testObject{}), logf.Array("a", testArray{}))
+			},
+			expected: []string{`{"level":"INFO","msg":"test","o":{"x":1,"y":"s"},"a":[1,"s",{"x":1,"y":"s"},[1,"s"]]}`},
+		},
+		{
+			lineTag: ThisLine(),
+			name:    "Disabled",
+			log: func(logger *logf.Logger) {
+				logger.WithLevel(logf.LevelInfo).Debug("test")
+			},
+			expected: []string{""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t Test) {
+			t.AddLineTags(test.lineTag)
+
+			buffer := bytes.NewBuffer(nil)
+			handler := slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+				Level: slog.LevelDebug,
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			})
+
+			test.log(logf.NewLogger(logf.LevelDebug, slogf.NewEntryWriter(handler)))
+			t.Expect(strings.Split(strings.TrimSpace(buffer.String()), "\n")).To(Equal(test.expected))
+		})
+	}
+
+	t.Run("Caller", func(t Test) {
+		var records []slog.Record
+		handler := testHandler(func(record slog.Record) {
+			records = append(records, record)
+		})
+
+		logger := logf.NewLogger(logf.LevelDebug, slogf.NewEntryWriter(handler)).WithCaller()
+		_, file, line, _ := runtime.Caller(0)
+		logger.Info("test")
+
+		t.Expect(records).To(HaveLen(1))
+		frame, _ := runtime.CallersFrames([]uintptr{records[0].PC}).Next()
+		actual := fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		t.Expect(actual).To(Equal(fmt.Sprintf("%s:%d", filepath.Base(file), line+1)))
+	})
+}
+
+// ---
+
+type testObject struct{}
+
+func (testObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	enc.EncodeFieldInt64("x", 1)
+	enc.EncodeFieldString("y", "s")
+
+	return nil
+}
+
+// ---
+
+type testArray struct{}
+
+func (testArray) EncodeLogfArray(enc logf.TypeEncoder) error {
+	enc.EncodeTypeInt64(1)
+	enc.EncodeTypeString("s")
+	enc.EncodeTypeObject(testObject{})
+	enc.EncodeTypeArray(testArrayFlat{})
+
+	return nil
+}
+
+// ---
+
+type testArrayFlat struct{}
+
+func (testArrayFlat) EncodeLogfArray(enc logf.TypeEncoder) error {
+	enc.EncodeTypeInt64(1)
+	enc.EncodeTypeString("s")
+
+	return nil
+}
+
+// ---
+
+type testHandler func(slog.Record)
+
+func (h testHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h testHandler) Handle(_ context.Context, record slog.Record) error {
+	h(record)
+
+	return nil
+}
+
+func (h testHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h testHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// ---
+
+var (
+	_ slog.Handler       = testHandler(nil)
+	_ logf.ObjectEncoder = testObject{}
+	_ logf.ArrayEncoder  = testArray{}
+	_ logf.ArrayEncoder  = testArrayFlat{}
+)